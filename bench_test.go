@@ -61,3 +61,26 @@ func BenchmarkErrors(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkNew(b *testing.B) {
+	constructors := []struct {
+		name string
+		new  func(string) error
+	}{
+		{"New", New},
+		{"NewNoStack", NewNoStack},
+		{"NewSentinel", NewSentinel},
+		{"errors.New", stderrors.New},
+	}
+	for _, c := range constructors {
+		b.Run(c.name, func(b *testing.B) {
+			var err error
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				err = c.new("error")
+			}
+			b.StopTimer()
+			GlobalE = err
+		})
+	}
+}
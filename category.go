@@ -0,0 +1,19 @@
+package errors
+
+var categoryKind = &markerKind{name: "category", constructor: "WithCategory"}
+
+// WithCategory attaches a coarse category to err, such as "network", "db" or "auth",
+// for example to route logs of the error to different places.
+// Unlike a code, a category groups many different errors.
+func WithCategory(err error, category string) error {
+	return mark(err, categoryKind, category)
+}
+
+// Category gives the category attached with WithCategory.
+func Category(err error) (string, bool) {
+	category, ok := markerValue(err, categoryKind)
+	if !ok {
+		return "", false
+	}
+	return category.(string), true
+}
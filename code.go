@@ -0,0 +1,17 @@
+package errors
+
+var codeKind = &markerKind{name: "code", constructor: "WithCode"}
+
+// WithCode attaches a stable error code to err, for example to report in API responses.
+func WithCode(err error, code string) error {
+	return mark(err, codeKind, code)
+}
+
+// GetCode gives the code attached with WithCode.
+func GetCode(err error) (string, bool) {
+	code, ok := markerValue(err, codeKind)
+	if !ok {
+		return "", false
+	}
+	return code.(string), true
+}
@@ -0,0 +1,189 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// LimitedCollector runs functions concurrently, at most n at a time,
+// and collects the errors they return into an ErrorGroup.
+// A panic in a function is recovered and collected as an error with a stack trace.
+type LimitedCollector struct {
+	sem  chan struct{}
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	errs []error
+}
+
+// NewLimitedCollector creates a LimitedCollector that runs at most n functions at a time.
+// A value of n less than 1 is treated as 1.
+func NewLimitedCollector(n int) *LimitedCollector {
+	if n < 1 {
+		n = 1
+	}
+	return &LimitedCollector{sem: make(chan struct{}, n)}
+}
+
+// Go runs fn in a new goroutine.
+// Go blocks until fewer than n functions are running.
+func (c *LimitedCollector) Go(fn func() error) {
+	c.sem <- struct{}{}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer func() { <-c.sem }()
+		if err := runRecover(fn); err != nil {
+			c.mu.Lock()
+			c.errs = append(c.errs, err)
+			c.mu.Unlock()
+		}
+	}()
+}
+
+// Wait waits for all functions started with Go to return.
+// It returns an ErrorGroup of the errors they returned, or nil if there were none.
+func (c *LimitedCollector) Wait() error {
+	c.wg.Wait()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return newErrorGroup(c.errs)
+}
+
+// runRecover calls fn, converting a panic into an error.
+func runRecover(fn func() error) (err error) {
+	defer Recover(&err)
+	return fn()
+}
+
+// Recover converts a panic into an error stored in errp.
+// It must be deferred directly, usually with a named error result:
+//
+//	func doThing() (err error) {
+//		defer errors.Recover(&err)
+//		...
+//	}
+//
+// A panic value that is an error is given a stack trace with AddStack,
+// and the errors of NewMaybeStack and WrapMaybeStack in it record the stack trace of the panic.
+// Any other value becomes an error with the message "panic: <value>".
+// The stack trace starts where the panic happened.
+// The error of the panic replaces any error already in errp.
+// Recover does nothing when there is no panic.
+func Recover(errp *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	err := panicToError(r)
+	if errp == nil {
+		panic(err)
+	}
+	*errp = err
+}
+
+// panicToError must be called directly by the function that recovered the panic.
+func panicToError(r interface{}) error {
+	if err, ok := r.(error); ok {
+		if HasStack(err) {
+			if lazy := unrecordedStacks(err); len(lazy) > 0 {
+				st := panicStack()
+				for _, m := range lazy {
+					m.record(st)
+				}
+			}
+			return err
+		}
+		return &withStack{err, panicStack()}
+	}
+	return &fundamental{
+		msg:   fmt.Sprintf("panic: %v", r),
+		stack: panicStack(),
+	}
+}
+
+// panicStack records the stack trace starting where the panic happened,
+// skipping the frames of the recovering function and of the runtime panic handling.
+func panicStack() *stack {
+	st := callersSkip(5)
+	for i, pc := range *st {
+		if Frame(pc).name() != "runtime.gopanic" {
+			continue
+		}
+		i++
+		for i < len(*st) && strings.HasPrefix(Frame((*st)[i]).name(), "runtime.") {
+			i++
+		}
+		*st = (*st)[i:]
+		break
+	}
+	return st
+}
+
+// FanIn receives from all the channels until they are closed
+// and returns an ErrorGroup of the errors that are not nil,
+// with a stack trace recorded at the point FanIn was called.
+// It returns nil if no errors were received.
+func FanIn(chans ...<-chan error) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, ch := range chans {
+		wg.Add(1)
+		go func(ch <-chan error) {
+			defer wg.Done()
+			for err := range ch {
+				if err == nil {
+					continue
+				}
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(ch)
+	}
+	wg.Wait()
+
+	group := newErrorGroup(errs)
+	if group == nil {
+		return nil
+	}
+	return &withStack{
+		group,
+		callers(),
+	}
+}
+
+// Go runs each function in its own goroutine and waits for all of them to return.
+// It returns an ErrorGroup of the errors that are not nil,
+// in the order of the functions, or nil if they all succeeded.
+// Each error keeps the stack trace it recorded in its goroutine,
+// and a panic in a function is recovered as an error with a stack trace.
+func Go(funcs ...func() error) error {
+	return GoN(len(funcs), funcs...)
+}
+
+// GoN is like Go, but runs at most limit functions at a time.
+// The errors are collected the same as with Go.
+// A limit less than 1 is treated as 1.
+func GoN(limit int, funcs ...func() error) error {
+	if limit < 1 {
+		limit = 1
+	}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	results := make([]error, len(funcs))
+	for i, fn := range funcs {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, fn func() error) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = runRecover(fn)
+		}(i, fn)
+	}
+	wg.Wait()
+	return Join(results...)
+}
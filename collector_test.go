@@ -0,0 +1,259 @@
+package errors
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimitedCollector(t *testing.T) {
+	const limit = 3
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+
+	c := NewLimitedCollector(limit)
+	for i := 0; i < 10; i++ {
+		i := i
+		c.Go(func() error {
+			mu.Lock()
+			active++
+			if active > maxActive {
+				maxActive = active
+			}
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			active--
+			mu.Unlock()
+			if i%2 == 0 {
+				return Errorf("failed %d", i)
+			}
+			return nil
+		})
+	}
+	err := c.Wait()
+
+	if maxActive > limit {
+		t.Errorf("LimitedCollector: %d functions ran at once, want at most %d", maxActive, limit)
+	}
+	group, ok := err.(ErrorGroup)
+	if !ok {
+		t.Fatalf("LimitedCollector.Wait: got %T, want an ErrorGroup", err)
+	}
+	if got := len(group.Errors()); got != 5 {
+		t.Errorf("LimitedCollector.Wait: got %d errors, want 5", got)
+	}
+	for _, err := range group.Errors() {
+		if !HasStack(err) {
+			t.Errorf("LimitedCollector.Wait: error %v has no stack", err)
+		}
+	}
+}
+
+func TestLimitedCollectorPeakConcurrency(t *testing.T) {
+	const limit, total = 3, 8
+	var mu sync.Mutex
+	active, peak := 0, 0
+	started := make(chan struct{}, total)
+	release := make(chan struct{})
+
+	c := NewLimitedCollector(limit)
+	submitted := make(chan struct{})
+	go func() {
+		defer close(submitted)
+		for i := 0; i < total; i++ {
+			c.Go(func() error {
+				mu.Lock()
+				active++
+				if active > peak {
+					peak = active
+				}
+				mu.Unlock()
+				started <- struct{}{}
+				<-release
+				mu.Lock()
+				active--
+				mu.Unlock()
+				return nil
+			})
+		}
+	}()
+
+	for i := 0; i < limit; i++ {
+		<-started
+	}
+	select {
+	case <-started:
+		t.Fatalf("LimitedCollector: more than %d functions started before any returned", limit)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	<-submitted
+	if err := c.Wait(); err != nil {
+		t.Fatalf("LimitedCollector.Wait: got %v, want nil", err)
+	}
+
+	if peak != limit {
+		t.Errorf("LimitedCollector: peak concurrency %d, want %d", peak, limit)
+	}
+}
+
+func TestLimitedCollectorNoErrors(t *testing.T) {
+	c := NewLimitedCollector(2)
+	c.Go(func() error { return nil })
+	if err := c.Wait(); err != nil {
+		t.Errorf("LimitedCollector.Wait: got %v, want nil", err)
+	}
+}
+
+func TestLimitedCollectorPanic(t *testing.T) {
+	c := NewLimitedCollector(1)
+	c.Go(func() error { panic("boom") })
+	c.Go(func() error { panic(fmt.Errorf("bad state")) })
+	err := c.Wait()
+
+	group, ok := err.(ErrorGroup)
+	if !ok || len(group.Errors()) != 2 {
+		t.Fatalf("LimitedCollector.Wait: got %v, want 2 errors", err)
+	}
+	msgs := map[string]bool{}
+	for _, err := range group.Errors() {
+		msgs[err.Error()] = true
+		if !HasStack(err) {
+			t.Errorf("recovered panic %v has no stack", err)
+		}
+	}
+	if !msgs["panic: boom"] || !msgs["bad state"] {
+		t.Errorf("LimitedCollector.Wait: got %q, want panic messages", err)
+	}
+}
+
+func TestFanIn(t *testing.T) {
+	if err := FanIn(); err != nil {
+		t.Errorf("FanIn(): got %v, want nil", err)
+	}
+
+	produce := func(errs ...error) <-chan error {
+		ch := make(chan error)
+		go func() {
+			defer close(ch)
+			for _, err := range errs {
+				ch <- err
+			}
+		}()
+		return ch
+	}
+
+	if err := FanIn(produce(nil), produce(nil, nil)); err != nil {
+		t.Errorf("FanIn of nil errors: got %v, want nil", err)
+	}
+
+	err := FanIn(
+		produce(New("a1"), nil, New("a2")),
+		produce(nil, New("b1")),
+		produce(),
+		produce(New("c1"), New("c2"), New("c3")),
+	)
+	if err == nil {
+		t.Fatal("FanIn: got nil, want errors")
+	}
+	origin, _ := StackOrigin(err.(StackTracer))
+	if got := origin.Function(); got != "TestFanIn" {
+		t.Errorf("FanIn: stack starts at %q, want TestFanIn", got)
+	}
+	group, ok := Cause(err).(ErrorGroup)
+	if !ok {
+		t.Fatalf("FanIn: got cause %T, want an ErrorGroup", Cause(err))
+	}
+	got := map[string]bool{}
+	for _, err := range group.Errors() {
+		got[err.Error()] = true
+	}
+	for _, msg := range []string{"a1", "a2", "b1", "c1", "c2", "c3"} {
+		if !got[msg] {
+			t.Errorf("FanIn: %s not collected", msg)
+		}
+	}
+	if len(group.Errors()) != 6 {
+		t.Errorf("FanIn: got %d errors, want 6", len(group.Errors()))
+	}
+}
+
+func goWorkerA() error { return New("worker a failed") }
+func goWorkerB() error { return New("worker b failed") }
+
+func TestGo(t *testing.T) {
+	if err := Go(); err != nil {
+		t.Errorf("Go(): got %v, want nil", err)
+	}
+	if err := Go(func() error { return nil }, func() error { return nil }); err != nil {
+		t.Errorf("Go with no errors: got %v, want nil", err)
+	}
+
+	err := Go(
+		goWorkerA,
+		func() error { return nil },
+		goWorkerB,
+		func() error { panic("boom") },
+	)
+	group, ok := err.(ErrorGroup)
+	if !ok {
+		t.Fatalf("Go: got %T, want an ErrorGroup", err)
+	}
+	errs := group.Errors()
+	if got, want := err.Error(), "worker a failed\nworker b failed\npanic: boom"; got != want {
+		t.Errorf("Go: got %q, want %q", got, want)
+	}
+	for i, want := range []string{"goWorkerA", "goWorkerB"} {
+		origin, _ := StackOrigin(errs[i].(StackTracer))
+		if got := origin.Function(); got != want {
+			t.Errorf("Go: error %d has a stack from %q, want %q", i, got, want)
+		}
+	}
+	if !HasStack(errs[2]) {
+		t.Errorf("Go: recovered panic %v has no stack", errs[2])
+	}
+}
+
+func TestGoN(t *testing.T) {
+	const limit = 2
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+
+	var funcs []func() error
+	for i := 0; i < 6; i++ {
+		i := i
+		funcs = append(funcs, func() error {
+			mu.Lock()
+			active++
+			if active > maxActive {
+				maxActive = active
+			}
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			active--
+			mu.Unlock()
+			if i%3 == 0 {
+				return Errorf("failed %d", i)
+			}
+			return nil
+		})
+	}
+	err := GoN(limit, funcs...)
+
+	if maxActive > limit {
+		t.Errorf("GoN: %d functions ran at once, want at most %d", maxActive, limit)
+	}
+	if got, want := fmt.Sprint(err), "failed 0\nfailed 3"; got != want {
+		t.Errorf("GoN: got %q, want %q", got, want)
+	}
+	if err := GoN(0, goWorkerA); err == nil || err.Error() != "worker a failed" {
+		t.Errorf("GoN with a limit of 0: got %v, want the error", err)
+	}
+}
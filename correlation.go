@@ -0,0 +1,18 @@
+package errors
+
+var correlationIDKind = &markerKind{name: "correlation_id", constructor: "WithCorrelationID"}
+
+// WithCorrelationID attaches a correlation identifier to err,
+// for example an idempotency key used to deduplicate retries across services.
+func WithCorrelationID(err error, id string) error {
+	return mark(err, correlationIDKind, id)
+}
+
+// CorrelationID gives the identifier attached with WithCorrelationID.
+func CorrelationID(err error) (string, bool) {
+	id, ok := markerValue(err, correlationIDKind)
+	if !ok {
+		return "", false
+	}
+	return id.(string), true
+}
@@ -0,0 +1,31 @@
+package errors
+
+import (
+	"log"
+)
+
+var devMode = false
+
+// SetDevMode turns on checks that are too noisy or expensive for production,
+// such as AssertStack.
+// This should be called during program initialization.
+func SetDevMode(enabled bool) {
+	devMode = enabled
+}
+
+// AssertStack returns err unchanged.
+// In dev mode (see SetDevMode) it also logs a warning with the call site
+// when err is not nil and has no stack trace anywhere in its chain.
+// Use it at boundaries that all errors should cross with a stack trace,
+// to find errors that leak out without AddStack.
+func AssertStack(err error) error {
+	if !devMode || err == nil || HasStack(err) {
+		return err
+	}
+	if origin, ok := StackOrigin(callers()); ok {
+		log.Printf("errors: error without a stack trace at %n (%v): %v", origin, origin, err)
+	} else {
+		log.Printf("errors: error without a stack trace: %v", err)
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package errors
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAssertStack(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	stacked := AddStack(io.EOF)
+	tests := []struct {
+		devMode bool
+		err     error
+		warns   bool
+	}{
+		{false, nil, false},
+		{false, io.EOF, false},
+		{true, nil, false},
+		{true, stacked, false},
+		{true, WithMessage(stacked, "read"), false},
+		{true, io.EOF, true},
+		{true, WithMessage(io.EOF, "read"), true},
+	}
+
+	for i, tt := range tests {
+		buf.Reset()
+		SetDevMode(tt.devMode)
+		if got := AssertStack(tt.err); got != tt.err {
+			t.Errorf("test %d: AssertStack: got %v, want %v", i+1, got, tt.err)
+		}
+		logged := buf.String()
+		if tt.warns != (logged != "") {
+			t.Errorf("test %d: AssertStack logged %q, want warning: %v", i+1, logged, tt.warns)
+		}
+		if tt.warns && !strings.Contains(logged, "TestAssertStack (dev_test.go:") {
+			t.Errorf("test %d: AssertStack warning %q does not have the call site", i+1, logged)
+		}
+	}
+	SetDevMode(false)
+}
@@ -0,0 +1,50 @@
+package errors
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// ToDOT renders the tree of errors as a Graphviz DOT graph.
+// Each node is an error labeled with the message it adds.
+// A wrapper that adds no message, such as WithStack, is labeled with its type.
+// A solid edge goes from an error to its cause,
+// and a dashed edge goes from a group to each of its errors.
+func ToDOT(err error) string {
+	var buf bytes.Buffer
+	buf.WriteString("digraph errors {\n")
+	if err != nil {
+		id := 0
+		writeDOTNode(&buf, err, &id)
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// dotEscaper escapes a label for a DOT quoted string.
+// Go quoting with %q would also escape tabs and non-ASCII characters
+// in a way that Graphviz prints literally.
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeDOTNode writes err and everything below it, returning the node id of err.
+func writeDOTNode(buf *bytes.Buffer, err error, id *int) int {
+	node := *id
+	*id++
+	label := localMessage(err)
+	if label == "" {
+		label = fmt.Sprintf("%T", err)
+	}
+	fmt.Fprintf(buf, "\t%d [label=\"%s\"];\n", node, dotEscaper.Replace(label))
+
+	if cause := Unwrap(err); cause != nil {
+		fmt.Fprintf(buf, "\t%d -> %d;\n", node, writeDOTNode(buf, cause, id))
+	}
+	for _, member := range groupErrors(err) {
+		if member == nil {
+			continue
+		}
+		fmt.Fprintf(buf, "\t%d -> %d [style=dashed];\n", node, writeDOTNode(buf, member, id))
+	}
+	return node
+}
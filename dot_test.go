@@ -0,0 +1,56 @@
+package errors
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+type dotGroup []error
+
+func (g dotGroup) Error() string   { return "group" }
+func (g dotGroup) Errors() []error { return g }
+
+func TestToDOT(t *testing.T) {
+	err := WithMessage(dotGroup{
+		WithMessage(io.EOF, "read"),
+		WithStack(New("write")),
+	}, "copy")
+
+	got := ToDOT(err)
+	for _, want := range []string{
+		"digraph errors {\n",
+		"\t0 [label=\"copy\"];\n",
+		"\t1 [label=\"group\"];\n",
+		"\t0 -> 1;\n",
+		"\t2 [label=\"read\"];\n",
+		"\t3 [label=\"EOF\"];\n",
+		"\t2 -> 3;\n",
+		"\t1 -> 2 [style=dashed];\n",
+		"\t4 [label=\"*errors.withStack\"];\n",
+		"\t5 [label=\"write\"];\n",
+		"\t4 -> 5;\n",
+		"\t1 -> 4 [style=dashed];\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("ToDOT: %q not found in:\n%s", want, got)
+		}
+	}
+	if n := strings.Count(got, "->"); n != 5 {
+		t.Errorf("ToDOT: got %d edges, want 5:\n%s", n, got)
+	}
+}
+
+func TestToDOTEscape(t *testing.T) {
+	got := ToDOT(New("open \"C:\\tmp\"\n\tcafé"))
+	want := "\t0 [label=\"open \\\"C:\\\\tmp\\\"\\n\tcafé\"];\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("ToDOT: %q not found in:\n%s", want, got)
+	}
+}
+
+func TestToDOTNil(t *testing.T) {
+	if got, want := ToDOT(nil), "digraph errors {\n}\n"; got != want {
+		t.Errorf("ToDOT(nil): got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,17 @@
+package errors
+
+import (
+	"io"
+)
+
+// IsEOF tells whether io.EOF is anywhere in the error tree,
+// including the errors of an ErrorGroup and errors wrapped with %w by fmt.Errorf.
+func IsEOF(err error) bool {
+	return is(err, io.EOF)
+}
+
+// IsUnexpectedEOF tells whether io.ErrUnexpectedEOF is anywhere in the error tree,
+// including the errors of an ErrorGroup and errors wrapped with %w by fmt.Errorf.
+func IsUnexpectedEOF(err error) bool {
+	return is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,33 @@
+package errors
+
+import (
+	"io"
+	"testing"
+)
+
+func TestIsEOF(t *testing.T) {
+	tests := []struct {
+		err             error
+		eof, unexpected bool
+	}{
+		{nil, false, false},
+		{io.EOF, true, false},
+		{io.ErrUnexpectedEOF, false, true},
+		{New("EOF"), false, false},
+		{Annotate(io.EOF, "read"), true, false},
+		{WithMessage(Annotate(io.ErrUnexpectedEOF, "read"), "decode"), false, true},
+		{Join(New("closed"), Annotate(io.EOF, "read")), true, false},
+		{Annotate(Join(io.ErrUnexpectedEOF), "read"), false, true},
+		{Annotate(unwrapOnly{io.EOF}, "read"), true, false},
+		{Join(New("closed"), unwrapOnly{Annotate(io.ErrUnexpectedEOF, "read")}), false, true},
+	}
+
+	for _, tt := range tests {
+		if got := IsEOF(tt.err); got != tt.eof {
+			t.Errorf("IsEOF(%v): got: %v, want %v", tt.err, got, tt.eof)
+		}
+		if got := IsUnexpectedEOF(tt.err); got != tt.unexpected {
+			t.Errorf("IsUnexpectedEOF(%v): got: %v, want %v", tt.err, got, tt.unexpected)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+//go:build go1.13
+// +build go1.13
+
+package errors
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestErrorfWrap(t *testing.T) {
+	err := Errorf("read %s: %w", "file", io.EOF)
+	if got, want := err.Error(), "read file: EOF"; got != want {
+		t.Errorf("Errorf: got %q, want %q", got, want)
+	}
+	if Cause(err) != io.EOF || !stderrors.Is(err, io.EOF) {
+		t.Errorf("Errorf: got cause %v, want %v", Cause(err), io.EOF)
+	}
+	if origin, _ := StackOrigin(GetStackTracer(err)); origin.Function() != "TestErrorfWrap" {
+		t.Errorf("Errorf: stack recorded at %q, want TestErrorfWrap", origin.Function())
+	}
+}
+
+func TestErrorfWrapStacked(t *testing.T) {
+	stacked := AddStack(io.EOF)
+	err := Errorf("x: %w", stacked)
+	if got, want := err.Error(), "x: EOF"; got != want {
+		t.Errorf("Errorf: got %q, want %q", got, want)
+	}
+	stacks := GetStackTracers(err)
+	if len(stacks) != 1 || stacks[0] != stacked.(StackTracer) {
+		t.Errorf("Errorf of a stacked error: got %d stacks, want only the wrapped one", len(stacks))
+	}
+	if Cause(err) != io.EOF || !stderrors.Is(err, io.EOF) {
+		t.Errorf("Errorf: got cause %v, want %v", Cause(err), io.EOF)
+	}
+
+	verbose := fmt.Sprintf("%+v", err)
+	if !strings.Contains(verbose, "TestErrorfWrapStacked") || !strings.HasSuffix(verbose, "\nx") || strings.Count(verbose, "EOF") != 1 {
+		t.Errorf("Errorf %%+v: got %q, want the wrapped stack and the message once", verbose)
+	}
+}
+
+func TestErrorfWrapFormat(t *testing.T) {
+	err := Errorf("read %s: %w", "file", io.EOF)
+	verbose := fmt.Sprintf("%+v", err)
+	if !strings.HasPrefix(verbose, "EOF\nread file\ngithub.com/pkg/errors.TestErrorfWrapFormat\n\t") {
+		t.Errorf("Errorf %%+v: got %q", verbose)
+	}
+	if got, want := fmt.Sprintf("%-v", err), "read file\nEOF"; got != want {
+		t.Errorf("Errorf %%-v: got %q, want %q", got, want)
+	}
+	if got, want := fmt.Sprintf("%#v", err), `errors.AddStack(errors.Errorf("read file: %w", &errors.errorString{s:"EOF"}))`; got != want {
+		t.Errorf("Errorf %%#v: got %q, want %q", got, want)
+	}
+	if got, want := fmt.Sprintf("%#v", Errorf("100%% of %w", New("a"))), `errors.Errorf("100%% of %w", errors.New("a"))`; got != want {
+		t.Errorf("Errorf %%#v: got %q, want %q", got, want)
+	}
+
+	// an escaped percent sign followed by w is not a %w verb
+	err = Errorf("100%%w %s", "done")
+	if got, want := err.Error(), "100%w done"; got != want {
+		t.Errorf("Errorf with %%%%w: got %q, want %q", got, want)
+	}
+	if Cause(err) != err {
+		t.Errorf("Errorf with %%%%w: got cause %v, want none", Cause(err))
+	}
+}
+
+func TestErrorfWrapJSONAndTrim(t *testing.T) {
+	err := Errorf("myservice: read: %w", New("myservice: EOF"))
+	b, jsonErr := json.Marshal(err)
+	if jsonErr != nil {
+		t.Fatalf("Errorf MarshalJSON: %v", jsonErr)
+	}
+	var decoded struct {
+		Message string
+		Cause   struct{ Message string }
+	}
+	if jsonErr := json.Unmarshal(b, &decoded); jsonErr != nil {
+		t.Fatalf("Errorf MarshalJSON: %v in %s", jsonErr, b)
+	}
+	if decoded.Message != "myservice: read" || decoded.Cause.Message != "myservice: EOF" {
+		t.Errorf("Errorf MarshalJSON: got %s", b)
+	}
+
+	trimmed := TrimMessagePrefix(err, "myservice: ")
+	if got, want := trimmed.Error(), "read: EOF"; got != want {
+		t.Errorf("TrimMessagePrefix of Errorf: got %q, want %q", got, want)
+	}
+	if got, want := Cause(trimmed).Error(), "EOF"; got != want {
+		t.Errorf("TrimMessagePrefix of Errorf: got cause %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,70 @@
+//go:build go1.20
+// +build go1.20
+
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"regexp"
+	"testing"
+)
+
+func errorfWorkerA() error { return New("a failed") }
+func errorfWorkerB() error { return New("b failed") }
+
+func TestErrorfMultipleWrap(t *testing.T) {
+	a, b := errorfWorkerA(), errorfWorkerB()
+	err := Errorf("both failed: %w; %w", a, b)
+
+	if got, want := err.Error(), "both failed: a failed; b failed"; got != want {
+		t.Errorf("Errorf: got %q, want %q", got, want)
+	}
+	if !stderrors.Is(err, a) || !stderrors.Is(err, b) {
+		t.Errorf("Errorf: errors.Is does not find the wrapped errors")
+	}
+	if got := Leaves(err); len(got) != 2 || got[0] != a || got[1] != b {
+		t.Errorf("Leaves of Errorf with multiple %%w: got %v, want [a b]", got)
+	}
+	if got := len(GetStackTracers(err)); got != 3 {
+		t.Errorf("Errorf with multiple %%w: got %d stacks, want 3", got)
+	}
+
+	verbose := fmt.Sprintf("%+v", err)
+	want := "^both failed: a failed; b failed\n" +
+		"github.com/pkg/errors.TestErrorfMultipleWrap\n\t.+/github.com/pkg/errors/errorf_go120_test.go:\\d+\n" +
+		"(?s:.*)\n\n" +
+		"a failed\ngithub.com/pkg/errors.errorfWorkerA\n(?s:.*)\n\n" +
+		"b failed\ngithub.com/pkg/errors.errorfWorkerB\n(?s:.*)$"
+	if !regexp.MustCompile(want).MatchString(verbose) {
+		t.Errorf("Errorf with multiple %%w %%+v: got\n%s\nwant match for\n%s", verbose, want)
+	}
+
+	joined := WithStack(stderrors.Join(io.EOF, a))
+	if verbose := fmt.Sprintf("%+v", joined); !regexp.MustCompile("(?s)\n\nEOF\n\na failed\ngithub.com/pkg/errors.errorfWorkerA\n").MatchString(verbose) {
+		t.Errorf("WithStack of errors.Join %%+v: got\n%s", verbose)
+	}
+}
+
+func TestWalkDeepStdlibWrappers(t *testing.T) {
+	a := errorfWorkerA()
+	for _, err := range []error{
+		fmt.Errorf("one: %w", a),
+		fmt.Errorf("both: %w %w", a, io.EOF),
+		WithMessage(fmt.Errorf("one: %w", a), "outer"),
+	} {
+		if !HasStack(err) {
+			t.Errorf("HasStack(%v): got false, want the stack of the wrapped error", err)
+		}
+		if GetStackTracer(err) != a.(StackTracer) {
+			t.Errorf("GetStackTracer(%v): got %v, want the stack of the wrapped error", err, GetStackTracer(err))
+		}
+		if Find(err, func(err error) bool { return err == a }) == nil {
+			t.Errorf("Find(%v): the wrapped error is not found", err)
+		}
+	}
+	if got := Leaves(fmt.Errorf("one: %w", a)); len(got) != 1 || got[0] != a {
+		t.Errorf("Leaves of fmt.Errorf with %%w: got %v, want [a]", got)
+	}
+}
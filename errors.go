@@ -62,6 +62,11 @@
 //     %v    see %s
 //     %+v   extended format. Each Frame of the error's StackTrace will
 //           be printed in detail.
+//     %-v   similar to %s, but the message of each error in the chain
+//           is printed on its own line, without stack traces.
+//     %#v   Go syntax that shows how the chain was built, such as
+//           errors.AddStack(errors.WithMessage(errors.New("a"), "b")).
+//           Stack traces are not printed.
 //
 // Retrieving the stack trace of an error or wrapper
 //
@@ -84,11 +89,24 @@
 // See the documentation for Frame.Format for more details.
 //
 // errors.Find can be used to search for an error in the error chain.
+//
+// Attaching values to an error
+//
+// Fatal, WithCode, WithCategory, WithCorrelationID, WithHTTPStatus, WithGRPCCode and WithTTL
+// attach a value to an error, which is read back with IsFatal, GetCode, Category,
+// CorrelationID, HTTPStatus, GRPCCode and IsStale.
+// They all follow the same rules. The message, formatting and stack trace of the error
+// are unchanged, and a nil error stays nil. The value is found anywhere in the
+// tree traversed by WalkDeep, so it survives further wrapping and groups,
+// including wrapping with %w by fmt.Errorf.
+// When the tree has more than one value of the same kind, the outermost one is used,
+// so that a caller can replace the value of an error it wraps.
 package errors
 
 import (
 	"fmt"
 	"io"
+	"strings"
 )
 
 // New returns an error with the supplied message.
@@ -103,13 +121,154 @@ func New(message string) error {
 // Errorf formats according to a format specifier and returns the string
 // as a value that satisfies error.
 // Errorf also records the stack trace at the point it was called.
+//
+// On Go 1.13 and later, the format can wrap an error with %w as with fmt.Errorf.
+// The wrapped error is the cause of the result.
+// If the wrapped error already has a stack trace, no stack trace is recorded,
+// as with AddStack.
+// With more than one %w, the result is a group of the wrapped errors.
 func Errorf(format string, args ...interface{}) error {
+	switch wrapVerbs(format) {
+	case 0:
+		return &fundamental{
+			msg:   fmt.Sprintf(format, args...),
+			stack: callers(),
+		}
+	case 1:
+		err := fmt.Errorf(format, args...)
+		cause := unwrapAny(err)
+		if cause == nil {
+			return &fundamental{
+				msg:   err.Error(),
+				stack: callers(),
+			}
+		}
+		wrapped := &wrappedf{msg: err.Error(), cause: cause}
+		if HasStack(cause) {
+			return wrapped
+		}
+		return &withStack{
+			wrapped,
+			callers(),
+		}
+	}
+	return &withStack{
+		fmt.Errorf(format, args...),
+		callers(),
+	}
+}
+
+// wrapVerbs counts the %w verbs of a format, skipping escaped percent signs.
+func wrapVerbs(format string) int {
+	n := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		for i < len(format) && strings.IndexByte("+-# 0123456789.*[]", format[i]) >= 0 {
+			i++
+		}
+		if i < len(format) && format[i] == 'w' {
+			n++
+		}
+	}
+	return n
+}
+
+// wrappedf is the result of Errorf wrapping a single error with %w.
+// When the wrapped error has no stack trace, Errorf adds one with withStack.
+type wrappedf struct {
+	msg   string
+	cause error
+}
+
+func (w *wrappedf) Error() string  { return w.msg }
+func (w *wrappedf) Cause() error   { return w.cause }
+func (w *wrappedf) Unwrap() error  { return w.cause }
+func (w *wrappedf) HasStack() bool { return HasStack(w.cause) }
+
+func (w *wrappedf) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "%+v", w.cause)
+			if msg := localMessage(w); msg != "" {
+				io.WriteString(s, "\n"+msg)
+			}
+			return
+		}
+		if s.Flag('-') {
+			if msg := localMessage(w); msg != "" {
+				io.WriteString(s, msg+"\n")
+			}
+			fmt.Fprintf(s, "%-v", w.cause)
+			return
+		}
+		if s.Flag('#') {
+			fmt.Fprintf(s, "errors.Errorf(%q, %#v)", w.format(), w.cause)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, w.msg)
+	case 'q':
+		fmt.Fprintf(s, "%q", w.msg)
+	}
+}
+
+// format gives a format for Errorf that results in the message of w,
+// with %w in place of the last occurrence of the message of the cause.
+func (w *wrappedf) format() string {
+	escape := func(s string) string { return strings.Replace(s, "%", "%%", -1) }
+	causeMsg := w.cause.Error()
+	i := strings.LastIndex(w.msg, causeMsg)
+	if i < 0 {
+		// the message of the cause was changed by the format, for example with %.3w:
+		// wrap it without printing it
+		return escape(w.msg) + "%.0w"
+	}
+	return escape(w.msg[:i]) + "%w" + escape(w.msg[i+len(causeMsg):])
+}
+
+// NewWithStack returns an error with the supplied message and stack trace,
+// rather than recording the stack at the point it was called.
+// This is useful for testing the formatting of stack traces with fixed stacks.
+func NewWithStack(message string, st StackTrace) error {
+	pcs := make(stack, len(st))
+	for i, f := range st {
+		pcs[i] = uintptr(f)
+	}
 	return &fundamental{
-		msg:   fmt.Sprintf(format, args...),
-		stack: callers(),
+		msg:   message,
+		stack: &pcs,
 	}
 }
 
+// NewNoStack returns an error with the supplied message,
+// but unlike New it does not record a stack trace.
+// This is much cheaper to create than New.
+// Use it on hot paths for errors that are expected to be checked and discarded
+// rather than reported.
+func NewNoStack(message string) error {
+	return &fundamentalNoStack{msg: message}
+}
+
+// NewSentinel is NewNoStack, named for declaring a package-level sentinel:
+//
+//	var ErrNotFound = errors.NewSentinel("not found")
+//
+// A sentinel is compared by identity with == on the result of Cause, or with errors.Is,
+// which works after it has been wrapped by any function of this package.
+// It has no stack trace,
+// since the stack of a package-level variable is the one of the program initialization.
+// Instead, the first AddStack or Annotate of the sentinel records a stack trace
+// where the error is returned.
+// The result is the same as from NewNoStack, so %#v prints it as errors.NewNoStack.
+func NewSentinel(message string) error {
+	return NewNoStack(message)
+}
+
 // StackTraceAware is an optimization to avoid repetitive traversals of an error chain.
 // HasStack checks for this marker first.
 // Annotate/Wrap and Annotatef/Wrapf will produce this marker.
@@ -141,6 +300,32 @@ func (f *fundamental) Format(s fmt.State, verb rune) {
 			f.stack.Format(s, verb)
 			return
 		}
+		if s.Flag('#') {
+			fmt.Fprintf(s, "errors.New(%q)", f.msg)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, f.msg)
+	case 'q':
+		fmt.Fprintf(s, "%q", f.msg)
+	}
+}
+
+// fundamentalNoStack is an error that has a message, but no stack or caller.
+type fundamentalNoStack struct {
+	msg string
+}
+
+func (f *fundamentalNoStack) Error() string { return f.msg }
+
+func (f *fundamentalNoStack) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('#') {
+			fmt.Fprintf(s, "errors.NewNoStack(%q)", f.msg)
+			return
+		}
 		fallthrough
 	case 's':
 		io.WriteString(s, f.msg)
@@ -152,25 +337,74 @@ func (f *fundamental) Format(s fmt.State, verb rune) {
 // WithStack annotates err with a stack trace at the point WithStack was called.
 // If err is nil, WithStack returns nil.
 //
-// Deprecated: use AddStack
+// Deprecated: use AddStack, or AddStackAlways to keep recording a stack trace when one exists.
 func WithStack(err error) error {
 	if err == nil {
 		return nil
 	}
+	return addStackAlways(err)
+}
+
+// AddStack is similar to WithStack.
+// However, it will first check with HasStack to see if a stack trace already exists in the causer chain before creating another one.
+// To record a new stack trace even if one exists, such as at a goroutine boundary, use AddStackAlways.
+func AddStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	return addStack(err)
+}
+
+// AddStackAlways records a stack trace at the point AddStackAlways was called,
+// even if err already has one.
+// Use it at a boundary such as a goroutine handoff:
+// GetStackTracers then gives a stack trace for each boundary the error went through.
+// If err is nil, AddStackAlways returns nil.
+func AddStackAlways(err error) error {
+	if err == nil {
+		return nil
+	}
+	return addStackAlways(err)
+}
+
+// Rethrow is AddStackAlways, named for returning an error from a different place than where it was created,
+// such as a cached error returned by a worker:
+// %+v will print both the original stack trace and the one from Rethrow.
+func Rethrow(err error) error {
+	if err == nil {
+		return nil
+	}
+	return addStackAlways(err)
+}
 
+// addStackAlways is AddStackAlways for a non-nil err, recording the stack of the caller of the exported function.
+func addStackAlways(err error) error {
 	return &withStack{
 		err,
-		callers(),
+		callersSkip(4),
 	}
 }
 
-// AddStack is similar to WithStack.
-// However, it will first check with HasStack to see if a stack trace already exists in the causer chain before creating another one.
-func AddStack(err error) error {
+// Must0 panics if err is not nil.
+// The panic value is err with a stack trace added by AddStack,
+// so that a recover handler can inspect it as an error.
+// See also Must and Must2 for functions that return values (Go 1.18 and later).
+func Must0(err error) {
+	if err != nil {
+		panic(addStack(err))
+	}
+}
+
+// addStack is AddStack for exported functions to call directly:
+// the stack trace starts at the caller of that function.
+func addStack(err error) error {
 	if HasStack(err) {
 		return err
 	}
-	return WithStack(err)
+	return &withStack{
+		err,
+		callersSkip(4),
+	}
 }
 
 // GetStackTracer will return the first StackTracer in the causer chain.
@@ -189,21 +423,107 @@ func GetStackTracer(origErr error) StackTracer {
 	return stacked
 }
 
+// GetStackTracers returns every StackTracer in the error tree, in the order of WalkDeep.
+// A stack identical to one already returned is skipped,
+// so re-wrapping an error does not produce repeated stacks.
+func GetStackTracers(origErr error) []StackTracer {
+	var stacks []StackTracer
+	WalkDeep(origErr, func(err error) bool {
+		stackTracer, ok := err.(StackTracer)
+		if !ok {
+			return false
+		}
+		for _, seen := range stacks {
+			if SameStack(seen, stackTracer) {
+				return false
+			}
+		}
+		stacks = append(stacks, stackTracer)
+		return false
+	})
+	return stacks
+}
+
+// StackFrames gives the Frames of the first stack trace in the error tree,
+// or nil when there is no stack trace.
+// The Function, File and Line methods of each Frame give what a tracing
+// library needs to record the stack, for example as a span event.
+func StackFrames(err error) []Frame {
+	stackTracer := GetStackTracer(err)
+	if stackTracer == nil {
+		return nil
+	}
+	return stackTracer.StackTrace()
+}
+
+// StackStrings gives a string for each Frame of the first stack trace in the error tree,
+// formatted as "function (file:line)".
+// The file path has the prefix set with SetSourcePrefixTrim removed.
+// This is convenient for loggers that take the stack trace as a list of strings.
+// It returns nil when there is no stack trace.
+func StackStrings(err error) []string {
+	stackTracer := GetStackTracer(err)
+	if stackTracer == nil {
+		return nil
+	}
+	st := stackTracer.StackTrace()
+	frames := make([]string, len(st))
+	for i, f := range st {
+		frames[i] = fmt.Sprintf("%s (%s:%d)", f.Function(), trimSourcePrefix(f.File()), f.Line())
+	}
+	return frames
+}
+
+// WalkStacks does the same traversal as WalkDeep,
+// but also gives the visitor the StackTrace recorded by each error.
+// The StackTrace is empty when that error did not record a stack.
+// This avoids walking the chain again to find the stack of each layer.
+func WalkStacks(err error, visitor func(err error, st StackTrace) bool) bool {
+	return WalkDeep(err, func(err error) bool {
+		var st StackTrace
+		if stackTracer, ok := err.(StackTracer); ok {
+			st = stackTracer.StackTrace()
+		}
+		return visitor(err, st)
+	})
+}
+
 type withStack struct {
 	error
 	*stack
 }
 
-func (w *withStack) Cause() error { return w.error }
+func (w *withStack) Cause() error  { return w.error }
+func (w *withStack) Unwrap() error { return w.error }
 
 func (w *withStack) Format(s fmt.State, verb rune) {
 	switch verb {
 	case 'v':
 		if s.Flag('+') {
+			if _, ok := w.error.(fmt.Formatter); !ok {
+				if members := groupErrors(w.error); len(members) > 0 {
+					// a group from another package, such as fmt.Errorf with multiple %w,
+					// does not print the stack traces of its errors
+					io.WriteString(s, w.Error())
+					w.stack.Format(s, verb)
+					for _, err := range members {
+						fmt.Fprintf(s, "\n\n%+v", err)
+					}
+					return
+				}
+			}
 			fmt.Fprintf(s, "%+v", w.Cause())
 			w.stack.Format(s, verb)
 			return
 		}
+		if s.Flag('-') {
+			fmt.Fprintf(s, "%-v", w.Cause())
+			return
+		}
+		if s.Flag('#') {
+			fmt.Fprintf(s, "errors.AddStack(%#v)", w.Cause())
+			return
+		}
 		fallthrough
 	case 's':
 		io.WriteString(s, w.Error())
@@ -254,6 +574,98 @@ func Wrapf(err error, format string, args ...interface{}) error {
 	}
 }
 
+// WrapIf annotates err in the same way as Annotate,
+// but only when pred(err) is true. Otherwise err is returned unchanged.
+// If err is nil, WrapIf returns nil without calling pred.
+func WrapIf(err error, pred func(error) bool, message string) error {
+	if err == nil || !pred(err) {
+		return err
+	}
+	return annotate(err, message)
+}
+
+// WrapReturn annotates the error pointed to by errp in the same way as Annotate.
+// It is meant to be deferred in a function with a named error result:
+//
+//     func doThing() (err error) {
+//             defer errors.WrapReturn(&err, "doThing")
+//             ...
+//     }
+//
+// The stack trace, if one is added, starts at the function that deferred WrapReturn.
+// WrapReturn does nothing if the error is nil.
+func WrapReturn(errp *error, message string) {
+	if errp == nil || *errp == nil {
+		return
+	}
+	*errp = annotate(*errp, message)
+}
+
+// WrapReturnf is WrapReturn with a format specifier, in the same way as Annotatef.
+func WrapReturnf(errp *error, format string, args ...interface{}) {
+	if errp == nil || *errp == nil {
+		return
+	}
+	*errp = annotate(*errp, fmt.Sprintf(format, args...))
+}
+
+// annotate is Annotate for exported functions to call directly:
+// the stack trace starts at the caller of that function.
+func annotate(err error, message string) error {
+	hasStack := HasStack(err)
+	err = &withMessage{
+		cause:         err,
+		msg:           message,
+		causeHasStack: hasStack,
+	}
+	if hasStack {
+		return err
+	}
+	return &withStack{
+		err,
+		callersSkip(4),
+	}
+}
+
+// WrapSkip is like Wrap, but skips frames of the stack trace like NewStack.
+// A skip of 0 starts the stack trace at the caller of WrapSkip.
+// Functions that wrap WrapSkip for their callers should give a skip of at least 1
+// so that the stack trace points to their caller rather than to themselves.
+// If err is nil, WrapSkip returns nil.
+func WrapSkip(err error, skip int, message string) error {
+	if err == nil {
+		return nil
+	}
+	hasStack := HasStack(err)
+	err = &withMessage{
+		cause:         err,
+		msg:           message,
+		causeHasStack: hasStack,
+	}
+	return &withStack{
+		err,
+		callersSkip(skip + 3),
+	}
+}
+
+// WrapfSkip is WrapSkip with a format specifier.
+// If err is nil, WrapfSkip returns nil.
+func WrapfSkip(err error, skip int, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	hasStack := HasStack(err)
+	err = &withMessage{
+		cause:         err,
+		msg:           fmt.Sprintf(format, args...),
+		causeHasStack: hasStack,
+	}
+	return &withStack{
+		err,
+		callersSkip(skip + 3),
+	}
+}
+
 // WithMessage annotates err with a new message.
 // If err is nil, WithMessage returns nil.
 func WithMessage(err error, message string) error {
@@ -267,14 +679,117 @@ func WithMessage(err error, message string) error {
 	}
 }
 
+// WithMessagef annotates err with a new message given by a format specifier.
+// Like WithMessage, it does not record a stack trace.
+// If err is nil, WithMessagef returns nil.
+func WithMessagef(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return &withMessage{
+		cause:         err,
+		msg:           fmt.Sprintf(format, args...),
+		causeHasStack: HasStack(err),
+	}
+}
+
+var includeCauseType = false
+
+// SetIncludeCauseType controls whether the Error() message of an error wrapped with a message
+// ends with the Go type of the root cause, for example:
+//
+//     outer: connection refused (*net.OpError)
+//
+// When the root cause is a group of this package, such as from Join,
+// the message ends with the type of the root cause of each of its errors, without repeats:
+//
+//     outer: a: EOF
+//     connection refused (*errors.errorString, *net.OpError)
+//
+// This helps identify the concrete type of an error without printing its stack trace.
+// It is off by default.
+// This should be called during program initialization.
+func SetIncludeCauseType(enabled bool) {
+	includeCauseType = enabled
+}
+
 type withMessage struct {
 	cause         error
 	msg           string
 	causeHasStack bool
 }
 
-func (w *withMessage) Error() string  { return w.msg + ": " + w.cause.Error() }
+func (w *withMessage) Error() string {
+	if !includeCauseType {
+		return w.msg + ": " + w.cause.Error()
+	}
+	var types []string
+	for _, root := range rootCauses(w.cause) {
+		t := fmt.Sprintf("%T", root)
+		if !containsString(types, t) {
+			types = append(types, t)
+		}
+	}
+	msg := w.msg + ": " + errorWithoutCauseType(w.cause)
+	causeTypes := " (" + strings.Join(types, ", ") + ")"
+	if strings.HasSuffix(msg, causeTypes) {
+		return msg
+	}
+	return msg + causeTypes
+}
+
+// rootCauses gives the result of Cause for err,
+// or for each error of a group of this package when that is the result.
+func rootCauses(err error) []error {
+	root := Cause(err)
+	var errs []error
+	switch g := root.(type) {
+	case *errorGroup:
+		errs = g.errs
+	case *ErrorList:
+		errs = g.errs
+	default:
+		return []error{root}
+	}
+	var roots []error
+	for _, err := range errs {
+		roots = append(roots, rootCauses(err)...)
+	}
+	return roots
+}
+
+func containsString(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
+
+// errorWithoutCauseType gives the Error() message of err
+// without the cause type that the messages of this package append,
+// so that only the outermost message appends it.
+func errorWithoutCauseType(err error) string {
+	switch e := err.(type) {
+	case *withMessage:
+		return e.msg + ": " + errorWithoutCauseType(e.cause)
+	case *withStack:
+		return errorWithoutCauseType(e.error)
+	case *maybeStack:
+		return errorWithoutCauseType(e.error)
+	case *marker:
+		return errorWithoutCauseType(e.cause)
+	case *errorGroup:
+		return e.message(errorWithoutCauseType)
+	case *ErrorList:
+		return e.message(errorWithoutCauseType)
+	}
+	return err.Error()
+}
+
 func (w *withMessage) Cause() error   { return w.cause }
+func (w *withMessage) Unwrap() error  { return w.cause }
 func (w *withMessage) HasStack() bool { return w.causeHasStack }
 
 func (w *withMessage) Format(s fmt.State, verb rune) {
@@ -285,6 +800,14 @@ func (w *withMessage) Format(s fmt.State, verb rune) {
 			io.WriteString(s, w.msg)
 			return
 		}
+		if s.Flag('-') {
+			fmt.Fprintf(s, "%s\n%-v", w.msg, w.Cause())
+			return
+		}
+		if s.Flag('#') {
+			fmt.Fprintf(s, "errors.WithMessage(%#v, %q)", w.Cause(), w.msg)
+			return
+		}
 		fallthrough
 	case 's', 'q':
 		io.WriteString(s, w.Error())
@@ -322,6 +845,74 @@ func Unwrap(err error) error {
 	return nil
 }
 
+// localMessage gives the part of the message of err that is not from its cause.
+// This is empty for wrappers that do not add a message, such as WithStack.
+func localMessage(err error) string {
+	if w, ok := err.(*withMessage); ok {
+		return w.msg
+	}
+	msg := err.Error()
+	cause := Unwrap(err)
+	if cause == nil {
+		return msg
+	}
+	causeMsg := cause.Error()
+	if msg == causeMsg {
+		return ""
+	}
+	return strings.TrimSuffix(msg, ": "+causeMsg)
+}
+
+// HasDuplicateMessages tells whether the same message was added more than once in the error chain,
+// which usually means the same context was added to an error repeatedly.
+// Only the message added by each error of the chain is compared, not the messages of its causes.
+// The errors of an ErrorGroup are separate chains, so they may have the same messages.
+func HasDuplicateMessages(err error) bool {
+	seen := map[string]bool{}
+	for ; err != nil; err = Unwrap(err) {
+		msg := localMessage(err)
+		if msg == "" {
+			continue
+		}
+		if seen[msg] {
+			return true
+		}
+		seen[msg] = true
+	}
+	return false
+}
+
+// UserError gives a short message to show to a user:
+// only the outermost message added to the chain,
+// without the messages of the causes and without stack traces.
+// For example, the user error of Annotate(io.EOF, "cannot read config") is "cannot read config".
+// An error that adds no message, such as AddStack, is skipped.
+func UserError(err error) string {
+	for ; err != nil; err = Unwrap(err) {
+		if msg := localMessage(err); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+// FormatCauseFirst gives the message of err with the messages of the chain in reverse order,
+// starting with the cause, for example "EOF: read: load config" instead of "load config: read: EOF".
+// err itself is not changed.
+// An ErrorGroup in the chain keeps its own message.
+func FormatCauseFirst(err error) string {
+	var msgs []string
+	for ; err != nil; err = Unwrap(err) {
+		if msg := localMessage(err); msg != "" {
+			msgs = append(msgs, msg)
+		}
+	}
+	for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+		msgs[i], msgs[j] = msgs[j], msgs[i]
+	}
+	return strings.Join(msgs, ": ")
+}
+
 // Find an error in the chain that matches a test function.
 // returns nil if no error is found.
 func Find(origErr error, test func(error) bool) error {
@@ -335,3 +926,76 @@ func Find(origErr error, test func(error) bool) error {
 	})
 	return foundErr
 }
+
+// ComposeWrap combines functions that wrap an error into one function.
+// The functions are applied in order, each wrapping the result of the previous one.
+// A nil error is returned as is, as is the result of a function that returns nil.
+//
+//     wrap := errors.ComposeWrap(errors.AddStack, errors.Fatal)
+//     return wrap(err)
+func ComposeWrap(fns ...func(error) error) func(error) error {
+	return func(err error) error {
+		for _, fn := range fns {
+			if err == nil {
+				return nil
+			}
+			err = fn(err)
+		}
+		return err
+	}
+}
+
+// SafeFormat formats err with %+v like ErrorStack,
+// but it will not panic if an error in the chain has a broken Format or Error method.
+// If formatting panics, SafeFormat falls back to Error().
+// If Error() panics as well, a placeholder message is returned.
+// This is meant for logging paths that must never crash the process.
+func SafeFormat(err error) string {
+	if err == nil {
+		return ""
+	}
+	// fmt recovers panics of the Format and Error methods it calls,
+	// so each error of the tree is checked by calling its method directly.
+	if !WalkDeep(err, formatPanics) {
+		if formatted, ok := recoverString(func() string { return fmt.Sprintf("%+v", err) }); ok {
+			return formatted
+		}
+	}
+	if msg, ok := recoverString(err.Error); ok {
+		return msg
+	}
+	return fmt.Sprintf("%T: panic while formatting error", err)
+}
+
+// formatPanics tells whether the Format method of err, or its Error method if it has no Format method, panics.
+func formatPanics(err error) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+		}
+	}()
+	if formatter, ok := err.(fmt.Formatter); ok {
+		formatter.Format(discardState{}, 'v')
+	} else {
+		_ = err.Error()
+	}
+	return false
+}
+
+// discardState is a fmt.State for %+v that discards the output.
+type discardState struct{}
+
+func (discardState) Write(b []byte) (int, error) { return len(b), nil }
+func (discardState) Width() (int, bool)          { return 0, false }
+func (discardState) Precision() (int, bool)      { return 0, false }
+func (discardState) Flag(c int) bool             { return c == '+' }
+
+// recoverString calls f, reporting false if it panicked.
+func recoverString(f func() string) (s string, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			ok = false
+		}
+	}()
+	return f(), true
+}
@@ -4,8 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -369,3 +371,651 @@ func TestWalkDeep(t *testing.T) {
 		t.Errorf("found not exists")
 	}
 }
+
+func TestWalkStacks(t *testing.T) {
+	fundamental := New("origin")
+	withMessage := WithMessage(fundamental, "middle")
+	withStack := WithStack(withMessage)
+
+	var errs []error
+	var stacks []StackTrace
+	WalkStacks(withStack, func(err error, st StackTrace) bool {
+		errs = append(errs, err)
+		stacks = append(stacks, st)
+		return false
+	})
+
+	wantErrs := []error{withStack, withMessage, fundamental}
+	wantStacks := []StackTrace{
+		withStack.(StackTracer).StackTrace(),
+		nil,
+		fundamental.(StackTracer).StackTrace(),
+	}
+	if !reflect.DeepEqual(errs, wantErrs) {
+		t.Errorf("WalkStacks errors: got: %v, want %v", errs, wantErrs)
+	}
+	if !reflect.DeepEqual(stacks, wantStacks) {
+		t.Errorf("WalkStacks stacks: got: %v, want %v", stacks, wantStacks)
+	}
+}
+
+func TestNewNoStack(t *testing.T) {
+	err := NewNoStack("no stack")
+	if got, want := err.Error(), "no stack"; got != want {
+		t.Errorf("NewNoStack.Error(): got: %q, want %q", got, want)
+	}
+	if HasStack(err) {
+		t.Errorf("HasStack(NewNoStack): got true, want false")
+	}
+	if got, want := fmt.Sprintf("%+v", err), "no stack"; got != want {
+		t.Errorf("NewNoStack %%+v: got: %q, want %q", got, want)
+	}
+	if !HasStack(AddStack(err)) {
+		t.Errorf("HasStack(AddStack(NewNoStack)): got false, want true")
+	}
+}
+
+var globalErr error
+
+func TestNewNoStackAllocs(t *testing.T) {
+	withStack := testing.AllocsPerRun(100, func() { globalErr = New("error") })
+	noStack := testing.AllocsPerRun(100, func() { globalErr = NewNoStack("error") })
+	if noStack >= withStack {
+		t.Errorf("NewNoStack allocations: got %v, want less than New's %v", noStack, withStack)
+	}
+}
+
+type panicFormatError struct{ msg string }
+
+func (e panicFormatError) Error() string { return e.msg }
+
+func (e panicFormatError) Format(s fmt.State, verb rune) { panic("broken Format") }
+
+type panicError struct{}
+
+func (panicError) Error() string { panic("broken Error") }
+
+func TestSafeFormat(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{io.EOF, "EOF"},
+		{WithMessage(io.EOF, "read"), "EOF\nread"},
+		{panicFormatError{"fallback"}, "fallback"},
+		{WithMessage(panicFormatError{"fallback"}, "wrapped"), "wrapped: fallback"},
+		{panicError{}, "errors.panicError: panic while formatting error"},
+		{WithMessage(panicError{}, "wrapped"), "*errors.withMessage: panic while formatting error"},
+		{NewNoStack("text with (PANIC= in it"), "text with (PANIC= in it"},
+		{WithMessage(io.EOF, "text with (PANIC= in it"), "EOF\ntext with (PANIC= in it"},
+	}
+
+	for _, tt := range tests {
+		if got := SafeFormat(tt.err); got != tt.want {
+			t.Errorf("SafeFormat(%T): got: %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestWalkDeepPath(t *testing.T) {
+	err := &errWalkTest{
+		sub: []error{
+			&errWalkTest{
+				v:     10,
+				cause: &errWalkTest{v: 11},
+			},
+			&errWalkTest{
+				v:     20,
+				cause: &errWalkTest{v: 21, cause: &errWalkTest{v: 22}},
+			},
+		},
+	}
+
+	var got []string
+	WalkDeepPath(err, func(path []int, err error) bool {
+		got = append(got, fmt.Sprintf("%v=%v", path, err))
+		return false
+	})
+	want := []string{"[]=0", "[0]=10", "[0]=11", "[1]=20", "[1]=21", "[1]=22"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WalkDeepPath: got: %v, want %v", got, want)
+	}
+
+	var visited int
+	found := WalkDeepPath(err, func(path []int, err error) bool {
+		visited++
+		return err.(*errWalkTest).v == 11
+	})
+	if !found || visited != 3 {
+		t.Errorf("WalkDeepPath early return: got found=%v after %d visits, want true after 3", found, visited)
+	}
+}
+
+func TestComposeWrap(t *testing.T) {
+	var calls []string
+	wrapWith := func(msg string) func(error) error {
+		return func(err error) error {
+			calls = append(calls, msg)
+			return WithMessage(err, msg)
+		}
+	}
+
+	wrap := ComposeWrap(wrapWith("inner"), wrapWith("outer"))
+	if got, want := wrap(io.EOF).Error(), "outer: inner: EOF"; got != want {
+		t.Errorf("ComposeWrap: got: %q, want %q", got, want)
+	}
+
+	calls = nil
+	if got := wrap(nil); got != nil {
+		t.Errorf("ComposeWrap(nil): got %#v, expected nil", got)
+	}
+	if len(calls) != 0 {
+		t.Errorf("ComposeWrap(nil): called %v, expected no calls", calls)
+	}
+
+	calls = nil
+	discard := func(error) error { return nil }
+	if got := ComposeWrap(discard, wrapWith("outer"))(io.EOF); got != nil {
+		t.Errorf("ComposeWrap returning nil: got %#v, expected nil", got)
+	}
+	if len(calls) != 0 {
+		t.Errorf("ComposeWrap returning nil: called %v, expected no calls", calls)
+	}
+
+	if got := ComposeWrap()(io.EOF); got != io.EOF {
+		t.Errorf("ComposeWrap(): got %v, want %v", got, io.EOF)
+	}
+}
+
+func TestGetStackTracers(t *testing.T) {
+	if got := GetStackTracers(io.EOF); len(got) != 0 {
+		t.Errorf("GetStackTracers(io.EOF): got %v, want none", got)
+	}
+
+	inner := New("inner")
+	handoff := WithStack(WithMessage(inner, "handoff"))
+	got := GetStackTracers(handoff)
+	want := []StackTracer{handoff.(StackTracer), inner.(StackTracer)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetStackTracers: got %v, want %v", got, want)
+	}
+
+	stack := NewStack(0).(*stack)
+	same := &withStack{&withStack{io.EOF, stack}, stack}
+	if got := GetStackTracers(same); len(got) != 1 || got[0] != same {
+		t.Errorf("GetStackTracers with identical stacks: got %v, want only the outer error", got)
+	}
+}
+
+func TestWrapIf(t *testing.T) {
+	isEOF := func(err error) bool { return Cause(err) == io.EOF }
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{io.EOF, "retry exhausted: EOF"},
+		{Annotate(io.EOF, "read"), "retry exhausted: read: EOF"},
+		{io.ErrUnexpectedEOF, "unexpected EOF"},
+	}
+
+	for _, tt := range tests {
+		got := WrapIf(tt.err, isEOF, "retry exhausted")
+		if got.Error() != tt.want {
+			t.Errorf("WrapIf(%v): got: %q, want %q", tt.err, got, tt.want)
+		}
+		if isEOF(tt.err) && !HasStack(got) {
+			t.Errorf("WrapIf(%v): got no stack", tt.err)
+		}
+	}
+
+	if got := WrapIf(io.ErrUnexpectedEOF, isEOF, "retry exhausted"); got != io.ErrUnexpectedEOF {
+		t.Errorf("WrapIf without a match: got %#v, want the error unchanged", got)
+	}
+	called := false
+	if got := WrapIf(nil, func(error) bool { called = true; return true }, "retry exhausted"); got != nil || called {
+		t.Errorf("WrapIf(nil): got %#v, called predicate: %v", got, called)
+	}
+}
+
+func TestErrorCount(t *testing.T) {
+	tree := &errWalkTest{
+		sub: []error{
+			&errWalkTest{v: 10, cause: &errWalkTest{v: 11}},
+			&errWalkTest{
+				v: 20,
+				sub: []error{
+					&errWalkTest{v: 21},
+					&errWalkTest{v: 22, cause: &errWalkTest{v: 23}},
+				},
+			},
+			&errWalkTest{v: 30, cause: &errWalkTest{v: 31}},
+		},
+	}
+
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, 0},
+		{io.EOF, 1},
+		{Annotate(io.EOF, "read"), 1},
+		{tree, 4},
+	}
+
+	for _, tt := range tests {
+		if got := ErrorCount(tt.err); got != tt.want {
+			t.Errorf("ErrorCount(%v): got: %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestNewWithStack(t *testing.T) {
+	st := NewStack(0).StackTrace()[:1]
+	err := NewWithStack("fixed", st)
+
+	got := GetStackTracer(err).StackTrace()
+	if !reflect.DeepEqual(got, st) {
+		t.Errorf("NewWithStack: got stack %v, want %v", got, st)
+	}
+	want := fmt.Sprintf("fixed\n%+v", st[0])
+	if formatted := fmt.Sprintf("%+v", err); formatted != want {
+		t.Errorf("NewWithStack %%+v: got %q, want %q", formatted, want)
+	}
+
+	if formatted := fmt.Sprintf("%+v", NewWithStack("no frames", nil)); formatted != "no frames" {
+		t.Errorf("NewWithStack without frames: got %q, want %q", formatted, "no frames")
+	}
+}
+
+func TestSetIncludeCauseType(t *testing.T) {
+	err := Annotate(WithMessage(io.EOF, "inner"), "outer")
+	if got, want := err.Error(), "outer: inner: EOF"; got != want {
+		t.Errorf("Error() by default: got %q, want %q", got, want)
+	}
+
+	SetIncludeCauseType(true)
+	defer SetIncludeCauseType(false)
+	if got, want := err.Error(), "outer: inner: EOF (*errors.errorString)"; got != want {
+		t.Errorf("Error() with cause type: got %q, want %q", got, want)
+	}
+	if got, want := WithMessage(nilError{}, "outer").Error(), "outer: nil error (errors.nilError)"; got != want {
+		t.Errorf("Error() with cause type: got %q, want %q", got, want)
+	}
+	// The type is the one of the root cause, given once at the outermost message.
+	if got, want := Annotate(AddStack(io.EOF), "outer").Error(), "outer: EOF (*errors.errorString)"; got != want {
+		t.Errorf("Error() with cause type through a stack: got %q, want %q", got, want)
+	}
+	if got, want := Annotate(WithCode(Annotate(io.EOF, "inner"), "E1"), "outer").Error(), "outer: inner: EOF (*errors.errorString)"; got != want {
+		t.Errorf("Error() with cause type through a marker: got %q, want %q", got, want)
+	}
+	// A group gives the types of the root causes of its errors, once, at the end.
+	pathErr := &os.PathError{Op: "open", Path: "/x", Err: io.EOF}
+	group := WithMessage(Join(Annotate(io.EOF, "a"), io.ErrUnexpectedEOF, pathErr), "outer")
+	// os.PathError is an alias of fs.PathError since Go 1.16.
+	wantGroup := fmt.Sprintf("outer: a: EOF\nunexpected EOF\nopen /x: EOF (*errors.errorString, %T)", pathErr)
+	if got, want := group.Error(), wantGroup; got != want {
+		t.Errorf("Error() with cause type of a group: got %q, want %q", got, want)
+	}
+	if got, want := WithMessage(NewMaybeStack("lazy"), "outer").Error(), "outer: lazy (*errors.fundamentalNoStack)"; got != want {
+		t.Errorf("Error() with cause type of NewMaybeStack: got %q, want %q", got, want)
+	}
+	list := &ErrorList{}
+	list.Add(WithMessage(io.EOF, "a"))
+	if got, want := WithMessage(list, "outer").Error(), "outer: a: EOF (*errors.errorString)"; got != want {
+		t.Errorf("Error() with cause type of an ErrorList: got %q, want %q", got, want)
+	}
+	if got, want := WithMessage(Sanitize(Annotate(io.EOF, "read"), io.EOF), "outer").Error(), "outer: read: EOF (*errors.errorString)"; got != want {
+		t.Errorf("Error() with cause type of Sanitize: got %q, want %q", got, want)
+	}
+	if got, want := localMessage(err.(*withStack).Cause()), "outer"; got != want {
+		t.Errorf("localMessage with cause type: got %q, want %q", got, want)
+	}
+}
+
+func rethrowCached(err error) error { return Rethrow(err) }
+
+func TestRethrow(t *testing.T) {
+	if got := Rethrow(nil); got != nil {
+		t.Errorf("Rethrow(nil): got %#v, expected nil", got)
+	}
+
+	cached := stackOriginInner()
+	err := rethrowCached(cached)
+	if Cause(err) != cached {
+		t.Errorf("Rethrow: got cause %v, want %v", Cause(err), cached)
+	}
+	if got := len(GetStackTracers(err)); got != 2 {
+		t.Errorf("Rethrow: got %d stacks, want 2", got)
+	}
+	formatted := fmt.Sprintf("%+v", err)
+	for _, want := range []string{"errors.stackOriginInner\n\t", "errors.rethrowCached\n\t"} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("Rethrow %%+v: %q not found in %q", want, formatted)
+		}
+	}
+}
+
+func TestAddStackAlways(t *testing.T) {
+	if got := AddStackAlways(nil); got != nil {
+		t.Errorf("AddStackAlways(nil): got %#v, expected nil", got)
+	}
+
+	err := stackOriginInner()
+	if AddStack(err) != err {
+		t.Fatalf("AddStack: got a new error for an error with a stack")
+	}
+	err = AddStackAlways(AddStackAlways(err))
+	tracers := GetStackTracers(err)
+	if len(tracers) != 3 {
+		t.Fatalf("AddStackAlways: got %d stacks, want 3", len(tracers))
+	}
+	for _, tracer := range tracers[:2] {
+		if got := fmt.Sprintf("%n", tracer.StackTrace()[0]); got != "TestAddStackAlways" {
+			t.Errorf("AddStackAlways stack origin: got %q, want %q", got, "TestAddStackAlways")
+		}
+	}
+}
+
+func TestWalkDeepWrappedGroup(t *testing.T) {
+	err := Annotate(&errWalkTest{
+		v: 1,
+		sub: []error{
+			&errWalkTest{v: 10},
+			&errWalkTest{v: 20, cause: &errWalkTest{v: 21}},
+		},
+	}, "wrapped")
+
+	var paths []string
+	WalkDeepPath(err, func(path []int, err error) bool {
+		paths = append(paths, fmt.Sprint(path))
+		return false
+	})
+	want := []string{"[]", "[]", "[]", "[0]", "[1]", "[1]"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("WalkDeepPath of a wrapped group: got %v, want %v", paths, want)
+	}
+	if got := ErrorCount(err); got != 2 {
+		t.Errorf("ErrorCount of a wrapped group: got %d, want 2", got)
+	}
+}
+
+func TestStackStrings(t *testing.T) {
+	if got := StackStrings(io.EOF); got != nil {
+		t.Errorf("StackStrings(io.EOF): got %v, want nil", got)
+	}
+
+	err := Annotate(stackOriginInner(), "outer")
+	st := GetStackTracer(err).StackTrace()
+	got := StackStrings(err)
+	if len(got) != len(st) {
+		t.Fatalf("StackStrings: got %d frames, want %d", len(got), len(st))
+	}
+	f := st[0]
+	if want := fmt.Sprintf("stackOriginInner (%s:%d)", f.File(), f.Line()); got[0] != want {
+		t.Errorf("StackStrings: got %q, want %q", got[0], want)
+	}
+	if !strings.HasPrefix(got[1], "TestStackStrings (") {
+		t.Errorf("StackStrings: got %q, want the test function", got[1])
+	}
+}
+
+func wrapReturnEOF() (err error) {
+	defer WrapReturn(&err, "wrapReturnEOF")
+	return io.EOF
+}
+
+func wrapReturnfNil(id int) (err error) {
+	defer WrapReturnf(&err, "wrapReturnfNil %d", id)
+	return nil
+}
+
+func wrapReturnfStacked(id int) (err error) {
+	defer WrapReturnf(&err, "wrapReturnfStacked %d", id)
+	return stackOriginInner()
+}
+
+func TestWrapReturn(t *testing.T) {
+	err := wrapReturnEOF()
+	if got, want := err.Error(), "wrapReturnEOF: EOF"; got != want {
+		t.Errorf("WrapReturn: got %q, want %q", got, want)
+	}
+	origin, _ := StackOrigin(GetStackTracer(err))
+	if got := origin.Function(); got != "wrapReturnEOF" {
+		t.Errorf("WrapReturn: stack starts at %q, want wrapReturnEOF", got)
+	}
+
+	if err := wrapReturnfNil(1); err != nil {
+		t.Errorf("WrapReturnf with nil: got %v, want nil", err)
+	}
+	WrapReturn(nil, "nil pointer")
+
+	err = wrapReturnfStacked(2)
+	if got, want := err.Error(), "wrapReturnfStacked 2: inner"; got != want {
+		t.Errorf("WrapReturnf: got %q, want %q", got, want)
+	}
+	if got := len(GetStackTracers(err)); got != 1 {
+		t.Errorf("WrapReturnf: got %d stacks, want the original one", got)
+	}
+}
+
+func TestHasDuplicateMessages(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{io.EOF, false},
+		{Annotate(WithMessage(io.EOF, "read"), "open"), false},
+		{AddStack(WithStack(WithMessage(io.EOF, "read"))), false},
+		{Annotate(WithMessage(io.EOF, "read"), "read"), true},
+		{WithMessage(Annotate(WithMessage(New("EOF"), "read"), "open"), "read"), true},
+		{WithMessage(io.EOF, "EOF"), true},
+		{Join(WithMessage(io.EOF, "read"), WithMessage(io.ErrUnexpectedEOF, "read")), false},
+	}
+
+	for i, tt := range tests {
+		if got := HasDuplicateMessages(tt.err); got != tt.want {
+			t.Errorf("test %d: HasDuplicateMessages(%v): got %v, want %v", i+1, tt.err, got, tt.want)
+		}
+	}
+}
+
+func logAndWrap(err error, msg string) error {
+	return WrapSkip(err, 1, msg)
+}
+
+func logAndWrapf(err error, format string, args ...interface{}) error {
+	return WrapfSkip(err, 1, format, args...)
+}
+
+func TestWrapSkip(t *testing.T) {
+	if got := WrapSkip(nil, 0, "no error"); got != nil {
+		t.Errorf("WrapSkip(nil): got %#v, expected nil", got)
+	}
+	if got := WrapfSkip(nil, 0, "no error"); got != nil {
+		t.Errorf("WrapfSkip(nil): got %#v, expected nil", got)
+	}
+
+	tests := []struct {
+		err    error
+		msg    string
+		origin string
+	}{
+		{WrapSkip(io.EOF, 0, "read"), "read: EOF", "TestWrapSkip"},
+		{WrapfSkip(io.EOF, 0, "read %d", 1), "read 1: EOF", "TestWrapSkip"},
+		{logAndWrap(io.EOF, "read"), "read: EOF", "TestWrapSkip"},
+		{logAndWrapf(io.EOF, "read %d", 2), "read 2: EOF", "TestWrapSkip"},
+	}
+
+	for i, tt := range tests {
+		if got := tt.err.Error(); got != tt.msg {
+			t.Errorf("test %d: got %q, want %q", i+1, got, tt.msg)
+		}
+		origin, _ := StackOrigin(tt.err.(StackTracer))
+		if got := origin.Function(); got != tt.origin {
+			t.Errorf("test %d: stack starts at %q, want %q", i+1, got, tt.origin)
+		}
+	}
+}
+
+func TestFormatCauseFirst(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{io.EOF, "EOF"},
+		{Annotate(WithMessage(io.EOF, "read"), "load config"), "EOF: read: load config"},
+		{AddStack(WithMessage(WithStack(New("origin")), "middle")), "origin: middle"},
+		{Fatal(Annotatef(fmt.Errorf("x=%d", 1), "parse %s", "y")), "x=1: parse y"},
+	}
+
+	for i, tt := range tests {
+		if got := FormatCauseFirst(tt.err); got != tt.want {
+			t.Errorf("test %d: FormatCauseFirst(%v): got %q, want %q", i+1, tt.err, got, tt.want)
+		}
+	}
+
+	err := Annotate(io.EOF, "read")
+	FormatCauseFirst(err)
+	if err.Error() != "read: EOF" {
+		t.Errorf("FormatCauseFirst changed the error: got %q", err.Error())
+	}
+}
+
+type unwrapOnly struct{ err error }
+
+func (e unwrapOnly) Error() string { return "wrapped: " + e.err.Error() }
+func (e unwrapOnly) Unwrap() error { return e.err }
+
+func TestUnwrapMethod(t *testing.T) {
+	// Cause and Unwrap only follow Cause methods, as in pkg/errors:
+	// a chain is not changed by errors that start implementing Unwrap.
+	pathErr := &os.PathError{Op: "open", Path: "/missing", Err: io.EOF}
+	if got := Cause(Annotate(pathErr, "read")); got != pathErr {
+		t.Errorf("Cause through an Unwrap method: got %v, want %v", got, pathErr)
+	}
+	if got := Unwrap(unwrapOnly{io.EOF}); got != nil {
+		t.Errorf("Unwrap of an Unwrap method: got %v, want nil", got)
+	}
+}
+
+func TestStackFrames(t *testing.T) {
+	if got := StackFrames(io.EOF); got != nil {
+		t.Errorf("StackFrames(io.EOF): got %v, want nil", got)
+	}
+
+	frames := StackFrames(Annotate(stackOriginInner(), "outer"))
+	if len(frames) < 2 {
+		t.Fatalf("StackFrames: got %v, want the stack of stackOriginInner", frames)
+	}
+	if got := frames[0].Function(); got != "stackOriginInner" {
+		t.Errorf("StackFrames: got first frame %q, want stackOriginInner", got)
+	}
+	if got := frames[1].Function(); got != "TestStackFrames" {
+		t.Errorf("StackFrames: got second frame %q, want TestStackFrames", got)
+	}
+}
+
+func TestWithMessagef(t *testing.T) {
+	if got := WithMessagef(nil, "no error %d", 1); got != nil {
+		t.Errorf("WithMessagef(nil): got %#v, expected nil", got)
+	}
+
+	err := WithMessagef(io.EOF, "read %s at %d", "file", 3)
+	if got, want := err.Error(), "read file at 3: EOF"; got != want {
+		t.Errorf("WithMessagef: got %q, want %q", got, want)
+	}
+	if HasStack(err) {
+		t.Errorf("WithMessagef: got a stack, want none")
+	}
+	if !HasStack(WithMessagef(New("origin"), "read %d", 1)) {
+		t.Errorf("WithMessagef: HasStack does not report the stack of the cause")
+	}
+}
+
+var errSentinelTest = NewSentinel("sentinel")
+
+func returnSentinel() error { return AddStack(errSentinelTest) }
+
+func TestNewSentinel(t *testing.T) {
+	if HasStack(errSentinelTest) {
+		t.Errorf("NewSentinel: got a stack, want none")
+	}
+	if NewSentinel("sentinel") == errSentinelTest {
+		t.Errorf("NewSentinel: two sentinels with the same message are equal")
+	}
+	if got, want := fmt.Sprintf("%#v", errSentinelTest), `errors.NewNoStack("sentinel")`; got != want {
+		t.Errorf("NewSentinel %%#v: got %s, want %s", got, want)
+	}
+
+	err := Annotate(returnSentinel(), "lookup")
+	if Cause(err) != errSentinelTest {
+		t.Errorf("Cause of a wrapped sentinel: got %v, want the sentinel", Cause(err))
+	}
+	stacks := GetStackTracers(err)
+	if len(stacks) != 1 {
+		t.Fatalf("wrapped sentinel: got %d stacks, want 1", len(stacks))
+	}
+	if origin, _ := StackOrigin(stacks[0]); origin.Function() != "returnSentinel" {
+		t.Errorf("wrapped sentinel: stack recorded at %q, want returnSentinel", origin.Function())
+	}
+	if HasStack(errSentinelTest) {
+		t.Errorf("wrapping the sentinel changed it")
+	}
+}
+
+func TestWalkDeepPathNested(t *testing.T) {
+	a, b, c, d := NewNoStack("a"), NewNoStack("b"), NewNoStack("c"), NewNoStack("d")
+	err := Join(a, Annotate(Join(b, Join(c, d)), "wrapped"))
+
+	paths := map[error]string{}
+	WalkDeepPath(err, func(path []int, err error) bool {
+		paths[err] = fmt.Sprint(path)
+		return false
+	})
+	want := map[error]string{a: "[0]", b: "[1 0]", c: "[1 1 0]", d: "[1 1 1]"}
+	for err, path := range want {
+		if paths[err] != path {
+			t.Errorf("WalkDeepPath of %v: got %s, want %s", err, paths[err], path)
+		}
+	}
+}
+
+func TestUserError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{io.EOF, "EOF"},
+		{New("origin"), "origin"},
+		{Annotate(io.EOF, "cannot read config"), "cannot read config"},
+		{AddStack(WithCode(WithMessage(New("origin"), "cannot save"), "c")), "cannot save"},
+		{Annotatef(Annotate(io.EOF, "read"), "load %s", "config"), "load config"},
+	}
+
+	for i, tt := range tests {
+		if got := UserError(tt.err); got != tt.want {
+			t.Errorf("test %d: UserError(%v): got %q, want %q", i+1, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestAddStackOrigin(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  error
+	}{
+		{"AddStack", AddStack(io.EOF)},
+		{"Trace", Trace(io.EOF)},
+	} {
+		if origin, _ := StackOrigin(GetStackTracer(tt.err)); origin.Function() != "TestAddStackOrigin" {
+			t.Errorf("%s: stack recorded at %q, want TestAddStackOrigin", tt.name, origin.Function())
+		}
+	}
+	if Trace(nil) != nil {
+		t.Errorf("Trace(nil): got an error, want nil")
+	}
+}
@@ -0,0 +1,33 @@
+// Package errvar counts errors by their code with expvar,
+// for lightweight in-process error metrics.
+// It is separate from package errors so that importing errors
+// does not publish any expvar variables.
+package errvar
+
+import (
+	"expvar"
+
+	"github.com/pkg/errors"
+)
+
+// NoCode is the key used for errors that have no code attached with errors.WithCode.
+const NoCode = "none"
+
+// ByCode holds the number of errors counted by CountByCode for each code.
+// It is published with expvar as "errors_by_code",
+// so it is served at /debug/vars along with the other expvar variables.
+var ByCode = expvar.NewMap("errors_by_code")
+
+// CountByCode increments the counter in ByCode for the code of err given by errors.GetCode,
+// or for NoCode if err has no code.
+// A nil error is not counted.
+func CountByCode(err error) {
+	if err == nil {
+		return
+	}
+	code, ok := errors.GetCode(err)
+	if !ok {
+		code = NoCode
+	}
+	ByCode.Add(code, 1)
+}
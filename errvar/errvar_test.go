@@ -0,0 +1,34 @@
+package errvar
+
+import (
+	"expvar"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func count(key string) int64 {
+	if v, ok := ByCode.Get(key).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func TestCountByCode(t *testing.T) {
+	before, beforeNone := count("not_found"), count(NoCode)
+
+	CountByCode(errors.WithCode(errors.New("missing"), "not_found"))
+	CountByCode(errors.Annotate(errors.WithCode(errors.New("missing"), "not_found"), "load"))
+	CountByCode(errors.New("uncoded"))
+	CountByCode(nil)
+
+	if got := count("not_found") - before; got != 2 {
+		t.Errorf("CountByCode: got %d more not_found, want 2", got)
+	}
+	if got := count(NoCode) - beforeNone; got != 1 {
+		t.Errorf("CountByCode: got %d more %s, want 1", got, NoCode)
+	}
+	if expvar.Get("errors_by_code") != ByCode {
+		t.Errorf("ByCode is not published as errors_by_code")
+	}
+}
@@ -0,0 +1,28 @@
+//go:build go1.23
+// +build go1.23
+
+package errors_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+func ExampleUnwrapGroupsLevel() {
+	err := errors.Join(
+		errors.NewNoStack("disk full"),
+		errors.WithMessage(errors.NewNoStack("timeout"), "upload"),
+	)
+	for level, err := range errors.UnwrapGroupsLevel(errors.WithMessage(err, "sync failed")) {
+		fmt.Printf("%s%s\n", strings.Repeat("  ", level), strings.Replace(err.Error(), "\n", " | ", -1))
+	}
+
+	// Output:
+	// sync failed: disk full | upload: timeout
+	//   disk full | upload: timeout
+	//     disk full
+	//     upload: timeout
+	//       timeout
+}
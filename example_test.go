@@ -2,6 +2,8 @@ package errors_test
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -203,3 +205,33 @@ func ExampleCause_printf() {
 
 	// Output: failed: hello world
 }
+
+func ExampleWalkDeepPath() {
+	err := errors.Join(
+		errors.NewNoStack("disk full"),
+		errors.WithMessage(errors.Join(
+			errors.NewNoStack("timeout"),
+			errors.NewNoStack("connection refused"),
+		), "upload"),
+	)
+
+	errors.WalkDeepPath(err, func(path []int, err error) bool {
+		if errors.Unwrap(err) != nil || len(path) == 0 {
+			return false
+		}
+		if _, ok := err.(errors.ErrorGroup); ok {
+			return false
+		}
+		address := make([]string, len(path))
+		for i, index := range path {
+			address[i] = strconv.Itoa(index + 1)
+		}
+		fmt.Printf("error %s: %v\n", strings.Join(address, "."), err)
+		return false
+	})
+
+	// Output:
+	// error 1: disk full
+	// error 2.1: timeout
+	// error 2.2: connection refused
+}
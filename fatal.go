@@ -0,0 +1,17 @@
+package errors
+
+var fatalKind = &markerKind{name: "fatal", constructor: "Fatal"}
+
+// Fatal marks err as fatal: the program should shut down instead of
+// logging the error and carrying on.
+// This is meant for the main loop of long-running processes,
+// and is distinct from how severe the error should be logged as.
+func Fatal(err error) error {
+	return mark(err, fatalKind, nil)
+}
+
+// IsFatal tells whether an error in the tree was marked with Fatal.
+func IsFatal(err error) bool {
+	_, ok := markerValue(err, fatalKind)
+	return ok
+}
@@ -532,3 +532,47 @@ func testGenericRecursive(t *testing.T, beforeErr error, beforeWant []string, li
 		}
 	}
 }
+
+func TestFormatMinusV(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{New("origin"), "origin"},
+		{NewNoStack("origin"), "origin"},
+		{io.EOF, "EOF"},
+		{WithMessage(io.EOF, "read"), "read\nEOF"},
+		{Annotate(WithMessage(New("origin"), "middle"), "outer"), "outer\nmiddle\norigin"},
+		{Wrapf(Fatal(WithMessage(io.EOF, "read")), "load %d", 1), "load 1\nread\nEOF"},
+		{WithMessage(Join(WithMessage(io.EOF, "a"), New("b")), "group"), "group\na\nEOF\nb"},
+	}
+
+	for i, tt := range tests {
+		if got := fmt.Sprintf("%-v", tt.err); got != tt.want {
+			t.Errorf("test %d: %%-v: got %q, want %q", i+1, got, tt.want)
+		}
+	}
+}
+
+func TestFormatSharpV(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{New("origin"), `errors.New("origin")`},
+		{NewNoStack("origin"), `errors.NewNoStack("origin")`},
+		{WithMessage(New("origin"), "read"), `errors.WithMessage(errors.New("origin"), "read")`},
+		{Annotate(NewNoStack("origin"), "read"), `errors.AddStack(errors.WithMessage(errors.NewNoStack("origin"), "read"))`},
+		{Fatal(WithStack(New("origin"))), `errors.Fatal(errors.AddStack(errors.New("origin")))`},
+		{Join(New("a"), NewNoStack("b")), `errors.Join(errors.New("a"), errors.NewNoStack("b"))`},
+		{WithMessage(io.EOF, "read"), fmt.Sprintf(`errors.WithMessage(%#v, "read")`, io.EOF)},
+		{Sanitize(New("secret")), `errors.Sanitize(errors.NewNoStack("secret"))`},
+		{Sanitize(WithMessage(io.EOF, "read"), io.EOF), fmt.Sprintf(`errors.Sanitize(errors.NewNoStack("read: EOF"), %#v)`, io.EOF)},
+	}
+
+	for i, tt := range tests {
+		if got := fmt.Sprintf("%#v", tt.err); got != tt.want {
+			t.Errorf("test %d: %%#v: got %s, want %s", i+1, got, tt.want)
+		}
+	}
+}
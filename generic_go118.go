@@ -0,0 +1,90 @@
+//go:build go1.18
+// +build go1.18
+
+package errors
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// WrapMapErrors annotates each non-nil error of a map with its key
+// and returns them as an ErrorGroup, or nil if there are no errors.
+// The message format is given the key, for example "user %v".
+// The errors are ordered by key so that the result is deterministic:
+// numbers and strings are compared by value, other keys by their formatted text.
+func WrapMapErrors[K comparable](errs map[K]error, msgFmt string) error {
+	type keyed struct {
+		key reflect.Value
+		err error
+	}
+	var wrapped []keyed
+	for k, err := range errs {
+		if err == nil {
+			continue
+		}
+		wrapped = append(wrapped, keyed{reflect.ValueOf(k), annotate(err, fmt.Sprintf(msgFmt, k))})
+	}
+	sort.Slice(wrapped, func(i, j int) bool { return lessKey(wrapped[i].key, wrapped[j].key) })
+
+	group := make([]error, len(wrapped))
+	for i, w := range wrapped {
+		group[i] = w.err
+	}
+	return newErrorGroup(group)
+}
+
+// lessKey orders two map keys of the same type.
+func lessKey(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	case reflect.String:
+		return a.String() < b.String()
+	}
+	return fmt.Sprint(a.Interface()) < fmt.Sprint(b.Interface())
+}
+
+// Must returns v if err is nil, and otherwise panics like Must0:
+// the panic value is err with a stack trace added by AddStack.
+//
+//	config := errors.Must(loadConfig())
+func Must[T any](v T, err error) T {
+	if err != nil {
+		panic(addStack(err))
+	}
+	return v
+}
+
+// Must2 is Must for functions that return two values and an error.
+func Must2[T, U any](v T, u U, err error) (T, U) {
+	if err != nil {
+		panic(addStack(err))
+	}
+	return v, u
+}
+
+// FindType returns the first error in the tree traversed by WalkDeep that has the type T,
+// which is either a concrete error type or an interface.
+// Like errors.As in the standard library, it follows Unwrap methods, such as of fmt.Errorf with %w.
+// Unlike errors.As, it also follows Cause methods and looks inside the members of an ErrorGroup,
+// including a group that is wrapped by another error,
+// and it only matches on the type: an As method of an error is not called.
+//
+//	if pathErr, ok := errors.FindType[*os.PathError](err); ok {
+func FindType[T any](err error) (T, bool) {
+	var found T
+	ok := WalkDeep(err, func(err error) bool {
+		if t, ok := err.(T); ok {
+			found = t
+			return true
+		}
+		return false
+	})
+	return found, ok
+}
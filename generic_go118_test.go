@@ -0,0 +1,130 @@
+//go:build go1.18
+// +build go1.18
+
+package errors
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWrapMapErrors(t *testing.T) {
+	errs := map[int]error{
+		3: io.ErrUnexpectedEOF,
+		1: io.EOF,
+		2: nil,
+	}
+	err := WrapMapErrors(errs, "user %d")
+
+	group, ok := err.(ErrorGroup)
+	if !ok {
+		t.Fatalf("WrapMapErrors: got %T, want an ErrorGroup", err)
+	}
+	members := group.Errors()
+	if len(members) != 2 {
+		t.Fatalf("WrapMapErrors: got %d errors, want 2", len(members))
+	}
+	if got, want := members[0].Error(), "user 1: EOF"; got != want {
+		t.Errorf("WrapMapErrors first error: got %q, want %q", got, want)
+	}
+	if got, want := members[1].Error(), "user 3: unexpected EOF"; got != want {
+		t.Errorf("WrapMapErrors second error: got %q, want %q", got, want)
+	}
+	if Cause(members[0]) != io.EOF {
+		t.Errorf("WrapMapErrors: got cause %v, want %v", Cause(members[0]), io.EOF)
+	}
+
+	if err := WrapMapErrors(map[string]error{"a": nil}, "key %s"); err != nil {
+		t.Errorf("WrapMapErrors without errors: got %v, want nil", err)
+	}
+
+	// Numeric keys are ordered by value, not by their text.
+	err = WrapMapErrors(map[int]error{10: io.EOF, 9: io.EOF, -1: io.EOF}, "user %d")
+	if got, want := err.Error(), "user -1: EOF\nuser 9: EOF\nuser 10: EOF"; got != want {
+		t.Errorf("WrapMapErrors order: got %q, want %q", got, want)
+	}
+
+	// The stack of each error starts at the caller.
+	err = WrapMapErrors(map[int]error{1: io.EOF}, "user %d")
+	st := err.(ErrorGroup).Errors()[0].(StackTracer).StackTrace()
+	if got, want := fmt.Sprintf("%n", st[0]), "TestWrapMapErrors"; got != want {
+		t.Errorf("WrapMapErrors stack origin: got %q, want %q", got, want)
+	}
+}
+
+func recoverError(t *testing.T, f func()) (err error) {
+	t.Helper()
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		var ok bool
+		if err, ok = r.(error); !ok {
+			t.Fatalf("panic value: got %#v, want an error", r)
+		}
+	}()
+	f()
+	return nil
+}
+
+func TestMust(t *testing.T) {
+	if got := Must(1, nil); got != 1 {
+		t.Errorf("Must(1, nil): got %v, want 1", got)
+	}
+	if a, b := Must2("a", 2, nil); a != "a" || b != 2 {
+		t.Errorf("Must2(a, 2, nil): got %v, %v", a, b)
+	}
+	if err := recoverError(t, func() { Must0(nil) }); err != nil {
+		t.Errorf("Must0(nil): panicked with %v", err)
+	}
+
+	tests := []func(){
+		func() { Must(0, io.EOF) },
+		func() { Must2(0, "", io.EOF) },
+		func() { Must0(io.EOF) },
+	}
+	for i, f := range tests {
+		err := recoverError(t, f)
+		if Cause(err) != io.EOF {
+			t.Errorf("test %d: panic value: got %v, want %v", i+1, err, io.EOF)
+			continue
+		}
+		origin, _ := StackOrigin(GetStackTracer(err))
+		if got := origin.Function(); !strings.HasPrefix(got, "TestMust.") {
+			t.Errorf("test %d: stack starts at %q, want the caller of Must", i+1, got)
+		}
+	}
+
+	stacked := New("stacked")
+	if err := recoverError(t, func() { Must(0, stacked) }); err != stacked {
+		t.Errorf("Must with a stack: got %v, want the error unchanged", err)
+	}
+}
+
+func TestFindType(t *testing.T) {
+	pathErr := &os.PathError{Op: "open", Path: "/x", Err: io.EOF}
+	err := Annotate(Join(New("first"), WithMessage(pathErr, "second")), "load")
+
+	found, ok := FindType[*os.PathError](err)
+	if !ok || found != pathErr {
+		t.Errorf("FindType: got %v %v, want %v true", found, ok, pathErr)
+	}
+	if group, ok := FindType[ErrorGroup](err); !ok || len(group.Errors()) != 2 {
+		t.Errorf("FindType[ErrorGroup]: got %v %v, want the group", group, ok)
+	}
+	for _, wrapped := range []error{fmt.Errorf("load: %w", pathErr), Annotate(fmt.Errorf("load: %w", pathErr), "outer")} {
+		if found, ok := FindType[*os.PathError](wrapped); !ok || found != pathErr {
+			t.Errorf("FindType through %%w: got %v %v, want %v true", found, ok, pathErr)
+		}
+	}
+	if found, ok := FindType[*os.PathError](New("plain")); ok || found != nil {
+		t.Errorf("FindType with no match: got %v %v, want nil false", found, ok)
+	}
+	if _, ok := FindType[*os.PathError](nil); ok {
+		t.Errorf("FindType(nil): got true, want false")
+	}
+}
@@ -1,5 +1,11 @@
 package errors
 
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
 // ErrorGroup is an interface for multiple errors that are not a chain.
 // This happens for example when executing multiple operations in parallel.
 type ErrorGroup interface {
@@ -7,27 +13,270 @@ type ErrorGroup interface {
 }
 
 // WalkDeep does a depth-first traversal of all errors.
-// Any ErrorGroup is traversed (after going deep).
+// The chain of an error is followed with its Cause method,
+// or else with an Unwrap() error method, such as from fmt.Errorf with %w.
+// Any group in the chain is traversed (after going deep):
+// an ErrorGroup, or an error with an Unwrap() []error method
+// such as from fmt.Errorf with multiple %w.
+// So errors wrapped by the standard library are part of the tree with either method,
+// unlike for Cause and Unwrap, which only follow Cause methods.
+// A group does not have to be the top-level error:
+// the members of a group wrapped with Annotate are visited as well.
 // The visitor function can return true to end the traversal early
 // In that case, WalkDeep will return true, otherwise false.
+// WalkDeep works with all Go versions.
+// On Go 1.23 and later, the iterators WithStacks and UnwrapGroupsPath
+// visit errors in the same order.
 func WalkDeep(err error, visitor func(err error) bool) bool {
+	return walkTree(nil, 0, err, func(_ []int, _ int, err error) bool {
+		return visitor(err)
+	})
+}
+
+// walkTree does the traversal of WalkDeep.
+// The visitor is also given the group path of WalkDeepPath
+// and the level of UnwrapGroupsLevel.
+func walkTree(path []int, level int, err error, visitor func(path []int, level int, err error) bool) bool {
 	// Go deep
-	unErr := err
-	for unErr != nil {
-		if done := visitor(unErr); done {
+	depth := 0
+	for unErr := err; unErr != nil; unErr = unwrapAny(unErr) {
+		if done := visitor(path, level+depth, unErr); done {
 			return true
 		}
-		unErr = Unwrap(unErr)
+		depth++
 	}
 
 	// Go wide
-	if group, ok := err.(ErrorGroup); ok {
-		for _, err := range group.Errors() {
-			if early := WalkDeep(err, visitor); early {
+	depth = 0
+	for unErr := err; unErr != nil; unErr = unwrapAny(unErr) {
+		for i, member := range groupErrors(unErr) {
+			if early := walkTree(appendPath(path, i), level+depth+1, member, visitor); early {
 				return true
 			}
 		}
+		depth++
 	}
 
 	return false
 }
+
+// ErrorCount gives the number of leaf errors in the tree traversed by WalkDeep.
+// For a group of failed operations this is the number of failures.
+func ErrorCount(err error) int {
+	return len(Leaves(err))
+}
+
+// Count gives the number of errors in the tree traversed by WalkDeep,
+// including wrappers and groups.
+// Use ErrorCount to count only the leaf errors.
+func Count(err error) int {
+	count := 0
+	WalkDeep(err, func(err error) bool {
+		count++
+		return false
+	})
+	return count
+}
+
+// Leaves gives the leaf errors in the tree, in the order of WalkDeep:
+// errors that have no cause and are not an ErrorGroup with errors in it.
+// These are the root causes, even when groups are nested in groups.
+func Leaves(err error) []error {
+	var leaves []error
+	WalkDeep(err, func(err error) bool {
+		if isLeaf(err) {
+			leaves = append(leaves, err)
+		}
+		return false
+	})
+	return leaves
+}
+
+func isLeaf(err error) bool {
+	if len(groupErrors(err)) > 0 {
+		return false
+	}
+	return unwrapAny(err) == nil
+}
+
+// groupErrors gives the errors of an ErrorGroup,
+// or of an error with an Unwrap() []error method.
+func groupErrors(err error) []error {
+	if group, ok := err.(ErrorGroup); ok {
+		return group.Errors()
+	}
+	if group, ok := err.(interface{ Unwrap() []error }); ok {
+		return group.Unwrap()
+	}
+	return nil
+}
+
+// unwrapAny gives the next error in the chain with Cause, or else with an Unwrap method.
+func unwrapAny(err error) error {
+	if cause := Unwrap(err); cause != nil {
+		return cause
+	}
+	if u, ok := err.(interface{ Unwrap() error }); ok {
+		return u.Unwrap()
+	}
+	return nil
+}
+
+// WalkDeepPath does the same traversal as WalkDeep,
+// but also gives the visitor the address of each error in the tree:
+// the index of the member for each group that was entered to reach the error.
+// The errors of the top-level chain have an empty path,
+// and an error and its causes have the same path, since unwrapping does not enter a group.
+// For example, [1, 0] is the first member of the group that is the second member
+// of the top-level group, which a report could show as "error 2.1".
+// The visitor can keep the path, but must not modify it.
+func WalkDeepPath(err error, visitor func(path []int, err error) bool) bool {
+	return walkTree(nil, 0, err, func(path []int, _ int, err error) bool {
+		return visitor(path, err)
+	})
+}
+
+// appendPath copies the path so that visitors can keep it.
+func appendPath(path []int, index int) []int {
+	newPath := make([]int, 0, len(path)+1)
+	newPath = append(newPath, path...)
+	return append(newPath, index)
+}
+
+// Join returns an ErrorGroup of the errors that are not nil,
+// or nil if there are none.
+// Each error keeps its own stack trace: formatting the group with %+v
+// prints each error with its stack trace, separated by a blank line.
+// This is useful for errors collected from multiple goroutines.
+func Join(errs ...error) error {
+	var group []error
+	for _, err := range errs {
+		if err != nil {
+			group = append(group, err)
+		}
+	}
+	return newErrorGroup(group)
+}
+
+// errorGroup is the ErrorGroup created by this package.
+type errorGroup struct {
+	errs []error
+}
+
+// newErrorGroup returns nil when there are no errors.
+func newErrorGroup(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &errorGroup{errs: errs}
+}
+
+func (g *errorGroup) Errors() []error { return g.errs }
+
+// Unwrap gives the errors of the group to errors.Is and errors.As of Go 1.20 and later.
+func (g *errorGroup) Unwrap() []error { return g.errs }
+
+var groupErrorIndexed = false
+
+// SetGroupErrorIndexed changes the Error() message of the groups created by this package,
+// such as with Join, to number each error and keep them on one line:
+//
+//	[1] first error; [2] second error
+//
+// By default each error is on its own line.
+// This should be called during program initialization.
+func SetGroupErrorIndexed(enabled bool) {
+	groupErrorIndexed = enabled
+}
+
+// Error gives the message of each error on its own line,
+// or numbered on one line when SetGroupErrorIndexed is enabled.
+func (g *errorGroup) Error() string {
+	return g.message(func(err error) string { return err.Error() })
+}
+
+// message joins the message of each error given by errorMessage, as for Error.
+func (g *errorGroup) message(errorMessage func(error) string) string {
+	msgs := make([]string, len(g.errs))
+	for i, err := range g.errs {
+		if groupErrorIndexed {
+			msgs[i] = fmt.Sprintf("[%d] %s", i+1, errorMessage(err))
+		} else {
+			msgs[i] = errorMessage(err)
+		}
+	}
+	if groupErrorIndexed {
+		return strings.Join(msgs, "; ")
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (g *errorGroup) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			for i, err := range g.errs {
+				if i > 0 {
+					io.WriteString(s, "\n\n")
+				}
+				fmt.Fprintf(s, "%+v", err)
+			}
+			return
+		}
+		if s.Flag('-') {
+			for i, err := range g.errs {
+				if i > 0 {
+					io.WriteString(s, "\n")
+				}
+				fmt.Fprintf(s, "%-v", err)
+			}
+			return
+		}
+		if s.Flag('#') {
+			io.WriteString(s, "errors.Join(")
+			for i, err := range g.errs {
+				if i > 0 {
+					io.WriteString(s, ", ")
+				}
+				fmt.Fprintf(s, "%#v", err)
+			}
+			io.WriteString(s, ")")
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, g.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", g.Error())
+	}
+}
+
+// ErrorList collects errors, for example from the iterations of a loop.
+// It is an ErrorGroup that formats the same as Join.
+// The zero value is an empty list ready to use.
+//
+//	var errs errors.ErrorList
+//	for _, item := range items {
+//		errs.Add(process(item))
+//	}
+//	return errs.ErrorOrNil()
+type ErrorList struct {
+	errorGroup
+}
+
+// Add appends err to the list, unless it is nil.
+func (l *ErrorList) Add(err error) {
+	if err != nil {
+		l.errs = append(l.errs, err)
+	}
+}
+
+// Len gives the number of errors in the list.
+func (l *ErrorList) Len() int { return len(l.errs) }
+
+// ErrorOrNil returns nil if the list is empty,
+// and otherwise an ErrorGroup of the errors added so far.
+// Errors added to the list later are not added to the returned error.
+func (l *ErrorList) ErrorOrNil() error {
+	return newErrorGroup(append([]error(nil), l.errs...))
+}
@@ -0,0 +1,169 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestJoin(t *testing.T) {
+	if err := Join(); err != nil {
+		t.Errorf("Join(): got %v, want nil", err)
+	}
+	if err := Join(nil, nil); err != nil {
+		t.Errorf("Join(nil, nil): got %v, want nil", err)
+	}
+
+	err := Join(io.EOF, nil, io.ErrUnexpectedEOF)
+	group, ok := err.(ErrorGroup)
+	if !ok || len(group.Errors()) != 2 {
+		t.Fatalf("Join: got %#v, want a group of 2 errors", err)
+	}
+	if got, want := err.Error(), "EOF\nunexpected EOF"; got != want {
+		t.Errorf("Join.Error(): got %q, want %q", got, want)
+	}
+	if got, want := fmt.Sprintf("%q", err), `"EOF\nunexpected EOF"`; got != want {
+		t.Errorf("Join %%q: got %s, want %s", got, want)
+	}
+}
+
+func joinWorkerFirst(errs chan<- error)  { errs <- New("first") }
+func joinWorkerSecond(errs chan<- error) { errs <- New("second") }
+
+func TestJoinStacks(t *testing.T) {
+	errs := make(chan error, 2)
+	go joinWorkerFirst(errs)
+	first := <-errs
+	go joinWorkerSecond(errs)
+	second := <-errs
+
+	err := Join(first, second)
+	if got := len(GetStackTracers(err)); got != 2 {
+		t.Errorf("GetStackTracers(Join): got %d stacks, want 2", got)
+	}
+
+	formatted := fmt.Sprintf("%+v", Annotate(err, "workers failed"))
+	parts := strings.Split(formatted, "\n\n")
+	if len(parts) != 2 {
+		t.Fatalf("Join %%+v: got %d parts, want 2:\n%s", len(parts), formatted)
+	}
+	for i, start := range []string{
+		"first\ngithub.com/pkg/errors.joinWorkerFirst\n\t",
+		"second\ngithub.com/pkg/errors.joinWorkerSecond\n\t",
+	} {
+		if !strings.HasPrefix(parts[i], start) {
+			t.Errorf("Join %%+v part %d: got %q, want it to start with %q", i, parts[i], start)
+		}
+	}
+	if !strings.HasSuffix(parts[1], "\nworkers failed") {
+		t.Errorf("Join %%+v: annotation not found at the end of %q", parts[1])
+	}
+}
+
+func TestSetGroupErrorIndexed(t *testing.T) {
+	err := Join(io.EOF, Annotate(io.ErrUnexpectedEOF, "read"))
+	if got, want := err.Error(), "EOF\nread: unexpected EOF"; got != want {
+		t.Errorf("Join.Error(): got %q, want %q", got, want)
+	}
+
+	SetGroupErrorIndexed(true)
+	defer SetGroupErrorIndexed(false)
+	if got, want := err.Error(), "[1] EOF; [2] read: unexpected EOF"; got != want {
+		t.Errorf("Join.Error() indexed: got %q, want %q", got, want)
+	}
+	if got, want := fmt.Sprintf("%v", err), "[1] EOF; [2] read: unexpected EOF"; got != want {
+		t.Errorf("Join %%v indexed: got %q, want %q", got, want)
+	}
+}
+
+func TestLeaves(t *testing.T) {
+	a, b, c := NewNoStack("a"), NewNoStack("b"), NewNoStack("c")
+	err := Annotate(Join(WithMessage(a, "first"), Join(b, Annotate(c, "third"))), "outer")
+
+	got := Leaves(err)
+	if len(got) != 3 || got[0] != a || got[1] != b || got[2] != c {
+		t.Errorf("Leaves: got %v, want [a b c]", got)
+	}
+	if got := Leaves(a); len(got) != 1 || got[0] != a {
+		t.Errorf("Leaves of a single error: got %v, want [a]", got)
+	}
+	if got := Leaves(nil); got != nil {
+		t.Errorf("Leaves(nil): got %v, want nil", got)
+	}
+}
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		err    error
+		count  int
+		leaves int
+	}{
+		{nil, 0, 0},
+		{io.EOF, 1, 1},
+		{Annotate(io.EOF, "read"), 3, 1},
+		{Join(io.EOF, io.ErrUnexpectedEOF), 3, 2},
+		{WithMessage(Join(Join(io.EOF, io.EOF), WithMessage(io.EOF, "x")), "y"), 7, 3},
+	}
+
+	for i, tt := range tests {
+		if got := Count(tt.err); got != tt.count {
+			t.Errorf("test %d: Count(%v): got %d, want %d", i+1, tt.err, got, tt.count)
+		}
+		if got := ErrorCount(tt.err); got != tt.leaves {
+			t.Errorf("test %d: ErrorCount(%v): got %d, want %d", i+1, tt.err, got, tt.leaves)
+		}
+	}
+}
+
+func TestErrorList(t *testing.T) {
+	var errs ErrorList
+	if errs.Len() != 0 || errs.ErrorOrNil() != nil {
+		t.Errorf("empty ErrorList: got %d errors, %v", errs.Len(), errs.ErrorOrNil())
+	}
+
+	errs.Add(nil)
+	errs.Add(io.EOF)
+	errs.Add(nil)
+	errs.Add(Annotate(io.ErrUnexpectedEOF, "read"))
+	if errs.Len() != 2 {
+		t.Errorf("ErrorList.Len: got %d, want 2", errs.Len())
+	}
+
+	err := errs.ErrorOrNil()
+	if got, want := err.Error(), Join(io.EOF, Annotate(io.ErrUnexpectedEOF, "read")).Error(); got != want {
+		t.Errorf("ErrorList.ErrorOrNil: got %q, want %q", got, want)
+	}
+	if got := errs.Error(); got != err.Error() {
+		t.Errorf("ErrorList.Error: got %q, want %q", got, err.Error())
+	}
+	if got := ErrorCount(&errs); got != 2 {
+		t.Errorf("ErrorCount(ErrorList): got %d, want 2", got)
+	}
+	if got := len(errs.Unwrap()); got != 2 {
+		t.Errorf("ErrorList.Unwrap: got %d errors, want 2", got)
+	}
+
+	errs.Add(io.EOF)
+	if got := len(err.(ErrorGroup).Errors()); got != 2 {
+		t.Errorf("ErrorOrNil changed after Add: got %d errors, want 2", got)
+	}
+}
+
+func TestWalkDeepGroupInChain(t *testing.T) {
+	// A group below a wrapper is traversed like a group at the top of the chain.
+	// Before, only a group at the top was:
+	// HasStack of this error was false, Find did not see io.EOF,
+	// and Annotate recorded a second stack trace instead of using the one of the member.
+	stacked := New("a")
+	err := &errWalkTest{v: 1, cause: Join(stacked, io.EOF)}
+	if !HasStack(err) {
+		t.Errorf("HasStack of a wrapped group: got false, want the stack of its member")
+	}
+	if Find(err, func(err error) bool { return err == io.EOF }) == nil {
+		t.Errorf("Find in a wrapped group: io.EOF not found")
+	}
+	if tracers := GetStackTracers(Annotate(err, "load")); len(tracers) != 1 || tracers[0] != stacked.(StackTracer) {
+		t.Errorf("Annotate of a wrapped group: got %d stacks, want only the one of its member", len(tracers))
+	}
+}
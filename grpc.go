@@ -0,0 +1,20 @@
+package errors
+
+var grpcCodeKind = &markerKind{name: "grpc_code", constructor: "WithGRPCCode"}
+
+// WithGRPCCode attaches a gRPC status code to err, such as the value of a codes.Code,
+// for a gRPC server to respond with.
+// The code is stored as a uint32 so that this package does not depend on gRPC.
+func WithGRPCCode(err error, code uint32) error {
+	return mark(err, grpcCodeKind, code)
+}
+
+// GRPCCode gives the code attached with WithGRPCCode.
+// Convert it with codes.Code(code).
+func GRPCCode(err error) (uint32, bool) {
+	code, ok := markerValue(err, grpcCodeKind)
+	if !ok {
+		return 0, false
+	}
+	return code.(uint32), true
+}
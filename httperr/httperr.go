@@ -0,0 +1,67 @@
+// Package httperr converts errors to HTTP responses.
+// It is separate from package errors so that importing errors
+// does not import net/http.
+package httperr
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// ContentType is the media type of the documents given by ToProblemJSON.
+const ContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details document.
+type Problem struct {
+	Type          string `json:"type"`
+	Title         string `json:"title"`
+	Status        int    `json:"status"`
+	Detail        string `json:"detail,omitempty"`
+	Category      string `json:"category,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// ToProblem gives the problem details of err:
+//
+//   - status is the status attached with errors.WithHTTPStatus, or 500
+//   - title is the code attached with errors.WithCode,
+//     or else the text of the status, as RFC 7807 recommends when the type is about:blank
+//   - type is always about:blank
+//   - detail is the outermost message given by errors.UserError
+//   - category and correlation_id are extension members for the values attached with
+//     errors.WithCategory and errors.WithCorrelationID
+//
+// The detail is shown to clients, so an error from a lower layer should be annotated
+// with a message meant for the client before it is converted.
+// A nil error gives a nil Problem.
+func ToProblem(err error) *Problem {
+	if err == nil {
+		return nil
+	}
+	status, ok := errors.HTTPStatus(err)
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	title, ok := errors.GetCode(err)
+	if !ok {
+		title = http.StatusText(status)
+	}
+	p := &Problem{
+		Type:   "about:blank",
+		Title:  title,
+		Status: status,
+		Detail: errors.UserError(err),
+	}
+	p.Category, _ = errors.Category(err)
+	p.CorrelationID, _ = errors.CorrelationID(err)
+	return p
+}
+
+// ToProblemJSON gives the JSON of the problem details of err given by ToProblem,
+// to send with the ContentType media type.
+// A nil error gives the JSON null.
+func ToProblemJSON(err error) ([]byte, error) {
+	return json.Marshal(ToProblem(err))
+}
@@ -0,0 +1,41 @@
+package httperr
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestToProblemJSON(t *testing.T) {
+	tests := []struct {
+		err  error
+		want map[string]interface{}
+	}{{
+		errors.WithCorrelationID(errors.WithCode(errors.WithHTTPStatus(errors.Annotate(io.EOF, "user not found"), 404), "user_not_found"), "req-1"),
+		map[string]interface{}{"type": "about:blank", "title": "user_not_found", "status": 404.0, "detail": "user not found", "correlation_id": "req-1"},
+	}, {
+		errors.WithCategory(io.EOF, "db"),
+		map[string]interface{}{"type": "about:blank", "title": "Internal Server Error", "status": 500.0, "detail": "EOF", "category": "db"},
+	}}
+
+	for i, tt := range tests {
+		b, err := ToProblemJSON(tt.err)
+		if err != nil {
+			t.Fatalf("test %d: %v", i+1, err)
+		}
+		var got map[string]interface{}
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatalf("test %d: %v in %s", i+1, err, b)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("test %d: got %s, want %v", i+1, b, tt.want)
+		}
+	}
+
+	if b, err := ToProblemJSON(nil); err != nil || string(b) != "null" {
+		t.Errorf("ToProblemJSON(nil): got %s %v, want null", b, err)
+	}
+}
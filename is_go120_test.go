@@ -0,0 +1,78 @@
+//go:build go1.20
+// +build go1.20
+
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestIsTransparent(t *testing.T) {
+	sentinel := io.ErrUnexpectedEOF
+	wrappers := []struct {
+		name string
+		wrap func(error) error
+	}{
+		{"Wrap", func(err error) error { return Wrap(err, "x") }},
+		{"Wrapf", func(err error) error { return Wrapf(err, "x %d", 1) }},
+		{"WrapIf", func(err error) error { return WrapIf(err, func(error) bool { return true }, "x") }},
+		{"WrapSkip", func(err error) error { return WrapSkip(err, 0, "x") }},
+		{"WrapfSkip", func(err error) error { return WrapfSkip(err, 0, "x %d", 1) }},
+		{"WrapReturn", func(err error) error {
+			WrapReturn(&err, "x")
+			return err
+		}},
+		{"WithStack", WithStack},
+		{"AddStack", AddStack},
+		{"AddStack of a new stack", func(err error) error { return AddStack(WithMessage(err, "x")) }},
+		{"Rethrow", Rethrow},
+		{"WithMessage", func(err error) error { return WithMessage(err, "x") }},
+		{"Annotate", func(err error) error { return Annotate(err, "x") }},
+		{"Annotatef", func(err error) error { return Annotatef(err, "x %d", 1) }},
+		{"Trace", Trace},
+		{"Fatal", Fatal},
+		{"WithCode", func(err error) error { return WithCode(err, "x") }},
+		{"WithCategory", func(err error) error { return WithCategory(err, "x") }},
+		{"WithCorrelationID", func(err error) error { return WithCorrelationID(err, "x") }},
+		{"WithHTTPStatus", func(err error) error { return WithHTTPStatus(err, 500) }},
+		{"WithTTL", func(err error) error { return WithTTL(err, 0) }},
+		{"Sanitize", func(err error) error { return Sanitize(err, sentinel) }},
+		{"TrimMessagePrefix", func(err error) error { return TrimMessagePrefix(Annotate(err, "p: x"), "p: ") }},
+		{"Join", func(err error) error { return Join(io.EOF, err) }},
+		{"Annotate of Join", func(err error) error { return Annotate(Join(err), "x") }},
+		{"fmt.Errorf", func(err error) error { return fmt.Errorf("x: %w", err) }},
+	}
+
+	for _, w := range wrappers {
+		err := w.wrap(sentinel)
+		if !stderrors.Is(err, sentinel) {
+			t.Errorf("%s: errors.Is(%v, sentinel): got false, want true", w.name, err)
+		}
+		for _, outer := range wrappers {
+			if !stderrors.Is(outer.wrap(err), sentinel) {
+				t.Errorf("%s of %s: errors.Is: got false, want true", outer.name, w.name)
+			}
+		}
+	}
+}
+
+func TestAsTransparent(t *testing.T) {
+	err := Annotate(Join(io.EOF, WithMessage(WithCode(New("origin"), "x"), "read")), "load")
+	var fundamentalErr *fundamental
+	if !stderrors.As(err, &fundamentalErr) || fundamentalErr.msg != "origin" {
+		t.Errorf("errors.As: got %v, want the origin error", fundamentalErr)
+	}
+}
+
+func TestIsSentinel(t *testing.T) {
+	err := Wrapf(Join(io.EOF, AddStack(errSentinelTest)), "batch %d", 1)
+	if !stderrors.Is(err, errSentinelTest) {
+		t.Errorf("errors.Is of a wrapped sentinel: got false, want true")
+	}
+	if stderrors.Is(err, NewSentinel("sentinel")) {
+		t.Errorf("errors.Is with another sentinel with the same message: got true, want false")
+	}
+}
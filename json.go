@@ -0,0 +1,180 @@
+package errors
+
+import (
+	"encoding/json"
+)
+
+// errorJSON is the JSON representation of the errors of this package.
+type errorJSON struct {
+	Message string                 `json:"message,omitempty"`
+	Cause   interface{}            `json:"cause,omitempty"`
+	Stack   StackTrace             `json:"stack,omitempty"`
+	Attrs   map[string]interface{} `json:"attrs,omitempty"`
+}
+
+// causeJSON gives a value that marshals to the JSON representation of err.
+// Errors from other packages are represented by their message,
+// or by an array for a group such as errors.Join of the standard library.
+func causeJSON(err error) interface{} {
+	if _, ok := err.(json.Marshaler); ok {
+		return err
+	}
+	if members := groupErrors(err); members != nil {
+		return groupJSON(members)
+	}
+	return errorJSON{Message: err.Error()}
+}
+
+func groupJSON(errs []error) []interface{} {
+	members := make([]interface{}, 0, len(errs))
+	for _, err := range errs {
+		if err != nil {
+			members = append(members, causeJSON(err))
+		}
+	}
+	return members
+}
+
+// MarshalJSON gives the message and stack trace of the error:
+//
+//	{"message":"...","stack":[{"func":"...","file":"...","line":1}]}
+func (f *fundamental) MarshalJSON() ([]byte, error) {
+	return json.Marshal(errorJSON{
+		Message: f.msg,
+		Stack:   f.StackTrace(),
+	})
+}
+
+// MarshalJSON gives the stack trace and the JSON of the cause:
+//
+//	{"cause":{...},"stack":[...]}
+func (w *withStack) MarshalJSON() ([]byte, error) {
+	return json.Marshal(errorJSON{
+		Cause: causeJSON(w.Cause()),
+		Stack: w.StackTrace(),
+	})
+}
+
+// MarshalJSON gives the message and the JSON of the cause:
+//
+//	{"message":"...","cause":{...}}
+func (w *withMessage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(errorJSON{
+		Message: w.msg,
+		Cause:   causeJSON(w.Cause()),
+	})
+}
+
+// MarshalJSON gives the message without the one of the cause, and the JSON of the cause,
+// as for WithMessage:
+//
+//	{"message":"...","cause":{...}}
+func (w *wrappedf) MarshalJSON() ([]byte, error) {
+	return json.Marshal(errorJSON{
+		Message: localMessage(w),
+		Cause:   causeJSON(w.Cause()),
+	})
+}
+
+// MarshalJSON gives the message of the error:
+//
+//	{"message":"..."}
+func (f *fundamentalNoStack) MarshalJSON() ([]byte, error) {
+	return json.Marshal(errorJSON{Message: f.msg})
+}
+
+// MarshalJSON gives an array with the JSON of each error of the group:
+//
+//	[{...},{...}]
+func (e *errorGroup) MarshalJSON() ([]byte, error) {
+	return json.Marshal(groupJSON(e.errs))
+}
+
+// MarshalJSON gives the attached value under its name, and the JSON of the cause:
+//
+//	{"cause":{...},"attrs":{"code":"E1"}}
+//
+// A Fatal error has the value true, and WithTTL gives the time at which the error is stale.
+func (m *marker) MarshalJSON() ([]byte, error) {
+	return json.Marshal(errorJSON{
+		Cause: causeJSON(m.Cause()),
+		Attrs: m.attrs(),
+	})
+}
+
+// attrs gives the attached value under its name for JSON.
+func (m *marker) attrs() map[string]interface{} {
+	var value interface{}
+	switch v := m.value.(type) {
+	case nil:
+		value = true
+	case ttl:
+		value = v.expires
+	default:
+		value = v
+	}
+	return map[string]interface{}{m.kind.name: value}
+}
+
+// MarshalJSON gives the JSON of the cause, and the stack trace if it was already recorded:
+// marshaling does not record it.
+//
+//	{"cause":{...},"stack":[...]}
+func (m *maybeStack) MarshalJSON() ([]byte, error) {
+	return json.Marshal(errorJSON{
+		Cause: causeJSON(m.Cause()),
+		Stack: m.StackTrace(),
+	})
+}
+
+// MarshalJSON gives only the message, as for %+v.
+func (s *sanitized) MarshalJSON() ([]byte, error) {
+	return json.Marshal(errorJSON{Message: s.msg})
+}
+
+// chainEntryJSON is an entry of MarshalChainJSON for one error of a chain.
+type chainEntryJSON struct {
+	Message string                 `json:"message,omitempty"`
+	Attrs   map[string]interface{} `json:"attrs,omitempty"`
+	Stack   StackTrace             `json:"stack,omitempty"`
+	Group   [][]chainEntryJSON     `json:"group,omitempty"`
+}
+
+// MarshalChainJSON gives the JSON of any error as an array with an entry
+// for each error of the chain that adds a message, a stack trace,
+// or a value such as a code, given in attrs as for MarshalJSON,
+// starting with the outermost error.
+// An ErrorGroup is an entry with an array for each of its errors:
+//
+//	[{"message":"load","stack":[...]},{"group":[[{"message":"EOF"}],[...]]}]
+//
+// This works the same for the errors of this package and errors from other packages.
+func MarshalChainJSON(err error) ([]byte, error) {
+	return json.Marshal(chainJSON(err))
+}
+
+func chainJSON(err error) []chainEntryJSON {
+	entries := []chainEntryJSON{}
+	for ; err != nil; err = Unwrap(err) {
+		var entry chainEntryJSON
+		if members := groupErrors(err); len(members) > 0 {
+			for _, member := range members {
+				if member != nil {
+					entry.Group = append(entry.Group, chainJSON(member))
+				}
+			}
+		} else {
+			entry.Message = localMessage(err)
+		}
+		if m, ok := err.(*marker); ok {
+			entry.Attrs = m.attrs()
+		}
+		if stackTracer, ok := err.(StackTracer); ok {
+			entry.Stack = stackTracer.StackTrace()
+		}
+		if entry.Message != "" || len(entry.Attrs) > 0 || len(entry.Stack) > 0 || len(entry.Group) > 0 {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,198 @@
+package errors
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type jsonChain struct {
+	Message string
+	Cause   *jsonChain
+	Stack   []struct {
+		Func string
+		File string
+		Line int
+	}
+	Attrs map[string]interface{}
+}
+
+func unmarshalChain(t *testing.T, err error) jsonChain {
+	data, jsonErr := json.Marshal(err)
+	if jsonErr != nil {
+		t.Fatalf("json.Marshal(%v): %v", err, jsonErr)
+	}
+	var chain jsonChain
+	if jsonErr := json.Unmarshal(data, &chain); jsonErr != nil {
+		t.Fatalf("json.Unmarshal(%s): %v", data, jsonErr)
+	}
+	return chain
+}
+
+func TestMarshalJSON(t *testing.T) {
+	chain := unmarshalChain(t, WithCode(Annotate(WithMessage(stackOriginInner(), "middle"), "outer"), "E1"))
+
+	if chain.Message != "" || chain.Attrs["code"] != "E1" || chain.Cause == nil {
+		t.Fatalf("code: got %+v", chain)
+	}
+	chain = *chain.Cause
+	if chain.Message != "outer" || len(chain.Stack) != 0 {
+		t.Errorf("outer: got %+v", chain)
+	}
+	middle := chain.Cause
+	if middle == nil || middle.Message != "middle" || len(middle.Stack) != 0 {
+		t.Fatalf("middle: got %+v", middle)
+	}
+	inner := middle.Cause
+	if inner == nil || inner.Message != "inner" || inner.Cause != nil {
+		t.Fatalf("inner: got %+v", inner)
+	}
+	if len(inner.Stack) == 0 || inner.Stack[0].Func != "stackOriginInner" {
+		t.Errorf("inner stack: got %+v", inner.Stack)
+	}
+}
+
+func TestMarshalJSONWithStack(t *testing.T) {
+	chain := unmarshalChain(t, Annotate(io.EOF, "read"))
+
+	if chain.Message != "" || len(chain.Stack) == 0 || chain.Stack[0].Func != "TestMarshalJSONWithStack" {
+		t.Errorf("stack: got %+v", chain)
+	}
+	read := chain.Cause
+	if read == nil || read.Message != "read" {
+		t.Fatalf("message: got %+v", read)
+	}
+	if read.Cause == nil || read.Cause.Message != "EOF" || read.Cause.Cause != nil {
+		t.Errorf("cause: got %+v", read.Cause)
+	}
+}
+
+func TestMarshalJSONAttrs(t *testing.T) {
+	clock := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	err := Fatal(WithHTTPStatus(WithGRPCCode(WithCategory(WithCorrelationID(WithTTL(io.EOF, time.Minute), "req-1"), "db"), 5), 404))
+	want := map[string]interface{}{
+		"fatal":          true,
+		"http_status":    404.0,
+		"grpc_code":      5.0,
+		"category":       "db",
+		"correlation_id": "req-1",
+		"ttl":            "2020-01-01T00:01:00Z",
+	}
+	got := map[string]interface{}{}
+	chain := unmarshalChain(t, err)
+	for c := &chain; c.Attrs != nil; c = c.Cause {
+		for k, v := range c.Attrs {
+			got[k] = v
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("attrs: got %v, want %v", got, want)
+	}
+}
+
+// foreignGroup is a group of errors from another package, as from errors.Join of Go 1.20.
+type foreignGroup []error
+
+func (g foreignGroup) Error() string   { return "group" }
+func (g foreignGroup) Unwrap() []error { return g }
+
+func TestMarshalJSONGroup(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{NewNoStack("a"), `{"message":"a"}`},
+		{Join(NewNoStack("a"), io.EOF), `[{"message":"a"},{"message":"EOF"}]`},
+		{WithMessage(Join(NewNoStack("a"), io.EOF), "load"), `{"message":"load","cause":[{"message":"a"},{"message":"EOF"}]}`},
+		{WithMessage(foreignGroup{io.EOF, io.ErrUnexpectedEOF}, "load"), `{"message":"load","cause":[{"message":"EOF"},{"message":"unexpected EOF"}]}`},
+		{Sanitize(Annotate(io.EOF, "read"), io.EOF), `{"message":"read: EOF"}`},
+	}
+	for i, tt := range tests {
+		b, err := json.Marshal(tt.err)
+		if err != nil {
+			t.Fatalf("test %d: %v", i+1, err)
+		}
+		if string(b) != tt.want {
+			t.Errorf("test %d: got %s, want %s", i+1, b, tt.want)
+		}
+	}
+
+	var list ErrorList
+	list.Add(NewNoStack("a"))
+	if b, _ := json.Marshal(&list); string(b) != `[{"message":"a"}]` {
+		t.Errorf("ErrorList: got %s", b)
+	}
+}
+
+type jsonChainEntry struct {
+	Message string
+	Attrs   map[string]interface{}
+	Stack   []struct {
+		Func string
+	}
+	Group [][]jsonChainEntry
+}
+
+func TestMarshalChainJSON(t *testing.T) {
+	err := Annotate(Join(stackOriginInner(), WithMessage(io.EOF, "read")), "load")
+	b, jsonErr := MarshalChainJSON(err)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	var entries []jsonChainEntry
+	if jsonErr := json.Unmarshal(b, &entries); jsonErr != nil {
+		t.Fatalf("%v: %s", jsonErr, b)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("MarshalChainJSON: got %d entries, want 2: %s", len(entries), b)
+	}
+	if entries[0].Message != "load" || len(entries[0].Stack) != 0 {
+		t.Errorf("message entry: got %+v", entries[0])
+	}
+	group := entries[1].Group
+	if len(group) != 2 || entries[1].Message != "" {
+		t.Fatalf("group entry: got %+v", entries[1])
+	}
+	if len(group[0]) != 1 || group[0][0].Message != "inner" || group[0][0].Stack[0].Func != "stackOriginInner" {
+		t.Errorf("first group member: got %+v", group[0])
+	}
+	if len(group[1]) != 2 || group[1][0].Message != "read" || group[1][1].Message != "EOF" {
+		t.Errorf("second group member: got %+v", group[1])
+	}
+}
+
+func TestMarshalChainJSONNil(t *testing.T) {
+	b, err := MarshalChainJSON(nil)
+	if err != nil || string(b) != "[]" {
+		t.Errorf("MarshalChainJSON(nil): got %s %v, want []", b, err)
+	}
+}
+
+func TestMarshalChainJSONAttrs(t *testing.T) {
+	b, jsonErr := MarshalChainJSON(WithHTTPStatus(WithCode(Annotate(io.EOF, "read"), "E1"), 404))
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	var entries []jsonChainEntry
+	if jsonErr := json.Unmarshal(b, &entries); jsonErr != nil {
+		t.Fatalf("%v: %s", jsonErr, b)
+	}
+	if len(entries) != 5 {
+		t.Fatalf("MarshalChainJSON: got %d entries, want 5: %s", len(entries), b)
+	}
+	if got := entries[0].Attrs; len(got) != 1 || got["http_status"] != 404.0 {
+		t.Errorf("status entry: got %+v", entries[0])
+	}
+	if got := entries[1].Attrs; len(got) != 1 || got["code"] != "E1" {
+		t.Errorf("code entry: got %+v", entries[1])
+	}
+	if entries[2].Attrs != nil || len(entries[2].Stack) == 0 || entries[3].Message != "read" || entries[4].Message != "EOF" {
+		t.Errorf("entries of the annotated error: got %s", b)
+	}
+}
@@ -6,10 +6,13 @@ import (
 
 // ==================== juju adaptor start ========================
 
-// Trace annotates err with a stack trace at the point WithStack was called.
+// Trace annotates err with a stack trace at the point Trace was called.
 // If err is nil or already contain stack trace return directly.
 func Trace(err error) error {
-	return AddStack(err)
+	if err == nil {
+		return nil
+	}
+	return addStack(err)
 }
 
 func Annotate(err error, message string) error {
@@ -0,0 +1,81 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+)
+
+// marker is the error returned by the functions that attach a value to an error,
+// such as WithCode. The rules they share are in the package documentation.
+type marker struct {
+	cause         error
+	causeHasStack bool
+	kind          *markerKind
+	value         interface{}
+}
+
+// markerKind tells apart the values attached by different functions.
+type markerKind struct {
+	// name of the value, such as "code"
+	name string
+	// constructor is the function that attaches the value, printed by %#v
+	constructor string
+}
+
+// mark attaches a value of the kind to err.
+func mark(err error, kind *markerKind, value interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return &marker{cause: err, causeHasStack: HasStack(err), kind: kind, value: value}
+}
+
+// markerValue gives the value of the outermost marker of the kind in the tree.
+func markerValue(err error, kind *markerKind) (interface{}, bool) {
+	found := Find(err, func(err error) bool {
+		m, ok := err.(*marker)
+		return ok && m.kind == kind
+	})
+	if found == nil {
+		return nil, false
+	}
+	return found.(*marker).value, true
+}
+
+func (m *marker) Error() string  { return m.cause.Error() }
+func (m *marker) Cause() error   { return m.cause }
+func (m *marker) Unwrap() error  { return m.cause }
+func (m *marker) HasStack() bool { return m.causeHasStack }
+
+func (m *marker) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "%+v", m.Cause())
+			return
+		}
+		if s.Flag('-') {
+			fmt.Fprintf(s, "%-v", m.Cause())
+			return
+		}
+		if s.Flag('#') {
+			fmt.Fprintf(s, "errors.%s(%#v", m.kind.constructor, m.Cause())
+			switch value := m.value.(type) {
+			case nil:
+			case ttl:
+				fmt.Fprintf(s, ", time.Duration(%d)", int64(value.duration))
+			case uint32:
+				fmt.Fprintf(s, ", %d", value)
+			default:
+				fmt.Fprintf(s, ", %#v", value)
+			}
+			io.WriteString(s, ")")
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, m.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", m.Error())
+	}
+}
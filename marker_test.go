@@ -0,0 +1,78 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestMarkers(t *testing.T) {
+	tests := []struct {
+		name     string
+		mark     func(error) error
+		found    func(error) bool
+		goSyntax string
+	}{
+		{"Fatal", Fatal, IsFatal, `errors.Fatal(errors.New("boom"))`},
+		{"WithCode", func(err error) error { return WithCode(err, "E1") }, func(err error) bool {
+			code, ok := GetCode(err)
+			return ok && code == "E1"
+		}, `errors.WithCode(errors.New("boom"), "E1")`},
+		{"WithCategory", func(err error) error { return WithCategory(err, "db") }, func(err error) bool {
+			category, ok := Category(err)
+			return ok && category == "db"
+		}, `errors.WithCategory(errors.New("boom"), "db")`},
+		{"WithCorrelationID", func(err error) error { return WithCorrelationID(err, "req-1") }, func(err error) bool {
+			id, ok := CorrelationID(err)
+			return ok && id == "req-1"
+		}, `errors.WithCorrelationID(errors.New("boom"), "req-1")`},
+		{"WithHTTPStatus", func(err error) error { return WithHTTPStatus(err, 404) }, func(err error) bool {
+			status, ok := HTTPStatus(err)
+			return ok && status == 404
+		}, `errors.WithHTTPStatus(errors.New("boom"), 404)`},
+		{"WithGRPCCode", func(err error) error { return WithGRPCCode(err, 5) }, func(err error) bool {
+			code, ok := GRPCCode(err)
+			return ok && code == 5
+		}, `errors.WithGRPCCode(errors.New("boom"), 5)`},
+		{"WithTTL", func(err error) error { return WithTTL(err, -time.Second) }, IsStale, `errors.WithTTL(errors.New("boom"), time.Duration(-1000000000))`},
+	}
+
+	for _, tt := range tests {
+		if got := tt.mark(nil); got != nil {
+			t.Errorf("%s(nil): got %v, want nil", tt.name, got)
+		}
+		if tt.found(io.EOF) || tt.found(nil) {
+			t.Errorf("%s: found a value on an unmarked error", tt.name)
+		}
+
+		cause := New("boom")
+		err := tt.mark(cause)
+		for _, format := range []string{"%s", "%v", "%+v"} {
+			if got, want := fmt.Sprintf(format, err), fmt.Sprintf(format, cause); got != want {
+				t.Errorf("%s: Sprintf(%q): got %q, want %q", tt.name, format, got, want)
+			}
+		}
+		if got := fmt.Sprintf("%#v", err); got != tt.goSyntax {
+			t.Errorf("%s: Sprintf(%%#v): got %q, want %q", tt.name, got, tt.goSyntax)
+		}
+		if Cause(err) != cause || !HasStack(err) || HasStack(tt.mark(io.EOF)) {
+			t.Errorf("%s: changed the cause or stack of the error", tt.name)
+		}
+		if !tt.found(err) || !tt.found(Annotate(err, "outer")) || !tt.found(Join(io.EOF, err)) || !tt.found(unwrapOnly{err}) {
+			t.Errorf("%s: value not found in the tree", tt.name)
+		}
+	}
+}
+
+func TestMarkerOutermost(t *testing.T) {
+	err := WithCode(Annotate(WithCode(io.EOF, "inner"), "read"), "outer")
+	if code, _ := GetCode(err); code != "outer" {
+		t.Errorf("GetCode: got %q, want %q", code, "outer")
+	}
+	// Different kinds do not hide each other.
+	err = WithCategory(WithCode(io.EOF, "E1"), "db")
+	if code, _ := GetCode(err); code != "E1" {
+		t.Errorf("GetCode: got %q, want %q", code, "E1")
+	}
+}
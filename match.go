@@ -0,0 +1,64 @@
+package errors
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// MatchType calls the first handler that accepts an error in the tree traversed by WalkDeep.
+// As for errors.As, this includes errors wrapped with %w by fmt.Errorf.
+// Each handler must be a function with a single argument,
+// which is a type that implements error or an interface.
+// Errors are tried in the order of WalkDeep,
+// and for each error the handlers are tried in the order given.
+// MatchType returns whether a handler was called.
+//
+//	matched := errors.MatchType(err,
+//		func(err *os.PathError) { ... },
+//		func(err net.Error) { ... },
+//	)
+func MatchType(err error, handlers ...interface{}) bool {
+	fns := make([]reflect.Value, len(handlers))
+	for i, handler := range handlers {
+		fn := reflect.ValueOf(handler)
+		if fn.Kind() != reflect.Func || fn.Type().NumIn() != 1 {
+			panic(fmt.Sprintf("errors: MatchType handler must be a function with one argument, got %T", handler))
+		}
+		in := fn.Type().In(0)
+		if in.Kind() != reflect.Interface && !in.Implements(errorType) {
+			panic(fmt.Sprintf("errors: MatchType handler argument must be an error or an interface, got %v", in))
+		}
+		fns[i] = fn
+	}
+
+	return WalkDeep(err, func(err error) bool {
+		v := reflect.ValueOf(err)
+		for _, fn := range fns {
+			if v.Type().AssignableTo(fn.Type().In(0)) {
+				fn.Call([]reflect.Value{v})
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// is tells whether target is in the tree traversed by WalkDeep, like errors.Is of the standard library,
+// which is not available before Go 1.13.
+// An Is(error) bool method of an error is used when present,
+// and errors are only compared with == when the type of target is comparable.
+func is(err, target error) bool {
+	if err == nil || target == nil {
+		return err == target
+	}
+	comparable := reflect.TypeOf(target).Comparable()
+	return WalkDeep(err, func(err error) bool {
+		if comparable && err == target {
+			return true
+		}
+		x, ok := err.(interface{ Is(error) bool })
+		return ok && x.Is(target)
+	})
+}
@@ -0,0 +1,65 @@
+package errors
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+type matchTestErr struct{ msg string }
+
+func (e matchTestErr) Error() string { return e.msg }
+
+func TestMatchType(t *testing.T) {
+	pathErr := &os.PathError{Op: "open", Path: "/x", Err: io.EOF}
+	err := Annotate(Join(matchTestErr{"first"}, pathErr), "load")
+
+	var got string
+	matched := MatchType(err,
+		func(err *os.PathError) { got = "path " + err.Op },
+		func(err matchTestErr) { got = "test " + err.msg },
+	)
+	if !matched || got != "test first" {
+		t.Errorf("MatchType: got %v %q, want true %q", matched, got, "test first")
+	}
+
+	got = ""
+	matched = MatchType(pathErr,
+		func(err ErrorGroup) { got = "group" },
+		func(err *os.PathError) { got = "path " + err.Op },
+		func(err error) { got = "error" },
+	)
+	if !matched || got != "path open" {
+		t.Errorf("MatchType: got %v %q, want true %q", matched, got, "path open")
+	}
+
+	got = ""
+	if !MatchType(Annotate(unwrapOnly{pathErr}, "load"), func(err *os.PathError) { got = "path " + err.Op }) || got != "path open" {
+		t.Errorf("MatchType through an Unwrap method: got %q, want %q", got, "path open")
+	}
+
+	got = ""
+	if MatchType(New("plain"), func(err *os.PathError) { got = "path" }) || got != "" {
+		t.Errorf("MatchType with no match: got true %q, want false", got)
+	}
+	if MatchType(nil, func(err error) {}) {
+		t.Errorf("MatchType(nil): got true, want false")
+	}
+}
+
+func TestMatchTypeInvalidHandler(t *testing.T) {
+	for _, handler := range []interface{}{
+		"not a function",
+		func() {},
+		func(s string) {},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("MatchType(%T): expected a panic", handler)
+				}
+			}()
+			MatchType(io.EOF, handler)
+		}()
+	}
+}
@@ -0,0 +1,139 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// NewMaybeStack returns an error with the supplied message
+// that records a stack trace only when it is needed:
+// the first time it is formatted with %+v, or when it is the value of a panic handled by Recover.
+// Until then, creating and returning the error costs about as little as NewNoStack.
+//
+// This is for hot paths where errors are usually checked and discarded.
+// The tradeoff is that the stack trace is the one of where the error was printed or recovered,
+// not of where it was created: the origin of the error can be lost.
+// HasStack reports true, so AddStack and Annotate do not record a stack trace of their own.
+// Use New when the origin matters.
+func NewMaybeStack(message string) error {
+	return &maybeStack{error: &fundamentalNoStack{msg: message}}
+}
+
+// WrapMaybeStack annotates err with a message like Annotate,
+// but when err has no stack trace, the stack trace is recorded as with NewMaybeStack.
+// If err is nil, WrapMaybeStack returns nil.
+func WrapMaybeStack(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	hasStack := HasStack(err)
+	err = &withMessage{
+		cause:         err,
+		msg:           message,
+		causeHasStack: hasStack,
+	}
+	if hasStack {
+		return err
+	}
+	return &maybeStack{error: err}
+}
+
+// maybeStack is withStack with a stack trace recorded on first use.
+type maybeStack struct {
+	error
+	mu    sync.Mutex
+	stack *stack
+}
+
+func (m *maybeStack) Cause() error   { return m.error }
+func (m *maybeStack) Unwrap() error  { return m.error }
+func (m *maybeStack) HasStack() bool { return true }
+
+// StackTrace is empty until the stack trace is recorded.
+func (m *maybeStack) StackTrace() StackTrace {
+	if st := m.recorded(); st != nil {
+		return st.StackTrace()
+	}
+	return nil
+}
+
+// recorded gives the stack trace, or nil if it is not recorded yet.
+func (m *maybeStack) recorded() *stack {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stack
+}
+
+// record sets the stack trace unless it is already recorded, and gives the recorded one.
+func (m *maybeStack) record(st *stack) *stack {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stack == nil {
+		m.stack = st
+	}
+	return m.stack
+}
+
+func (m *maybeStack) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			st := m.recorded()
+			if st == nil {
+				st = m.record(formatStack())
+			}
+			fmt.Fprintf(s, "%+v", m.Cause())
+			st.Format(s, verb)
+			return
+		}
+		if s.Flag('-') {
+			fmt.Fprintf(s, "%-v", m.Cause())
+			return
+		}
+		if s.Flag('#') {
+			if w, ok := m.error.(*withMessage); ok {
+				fmt.Fprintf(s, "errors.WrapMaybeStack(%#v, %q)", w.cause, w.msg)
+			} else {
+				fmt.Fprintf(s, "errors.NewMaybeStack(%q)", m.Error())
+			}
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, m.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", m.Error())
+	}
+}
+
+var packagePath = reflect.TypeOf(maybeStack{}).PkgPath()
+
+// formatStack records the stack trace of the caller of fmt,
+// skipping the frames of fmt and of the Format methods of this package.
+func formatStack() *stack {
+	st := callersSkip(3)
+	for i, pc := range *st {
+		name := Frame(pc).name()
+		if !strings.HasPrefix(name, "fmt.") && !strings.HasPrefix(name, packagePath+".(") {
+			*st = (*st)[i:]
+			break
+		}
+	}
+	return st
+}
+
+// unrecordedStacks gives the errors of NewMaybeStack and WrapMaybeStack in the tree
+// that do not have a stack trace yet.
+func unrecordedStacks(err error) []*maybeStack {
+	var lazy []*maybeStack
+	WalkDeep(err, func(err error) bool {
+		if m, ok := err.(*maybeStack); ok && m.recorded() == nil {
+			lazy = append(lazy, m)
+		}
+		return false
+	})
+	return lazy
+}
@@ -0,0 +1,87 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestNewMaybeStack(t *testing.T) {
+	err := NewMaybeStack("lazy")
+	if !HasStack(err) {
+		t.Errorf("NewMaybeStack: HasStack is false")
+	}
+	if AddStack(err) != err {
+		t.Errorf("AddStack of NewMaybeStack: got a new error")
+	}
+
+	for _, format := range []string{"%s", "%v", "%q", "%-v", "%#v"} {
+		fmt.Fprintf(ioutil.Discard, format, err)
+	}
+	if b, _ := json.Marshal(err); strings.Contains(string(b), "stack") {
+		t.Errorf("MarshalJSON recorded the stack: got %s", b)
+	}
+	if got := GetStackTracer(err).StackTrace(); len(got) != 0 {
+		t.Fatalf("NewMaybeStack: got a stack trace before %%+v: %v", got)
+	}
+
+	verbose := fmt.Sprintf("%+v", Annotate(err, "outer"))
+	if !strings.HasPrefix(verbose, "lazy\ngithub.com/pkg/errors.TestNewMaybeStack\n\t") {
+		t.Errorf("NewMaybeStack %%+v: got %q", verbose)
+	}
+	first := GetStackTracer(err)
+	if origin, _ := StackOrigin(first); origin.Function() != "TestNewMaybeStack" {
+		t.Errorf("NewMaybeStack: stack recorded at %q, want TestNewMaybeStack", origin.Function())
+	}
+	if fmt.Fprintf(ioutil.Discard, "%+v", err); !SameStack(first, GetStackTracer(err)) {
+		t.Errorf("NewMaybeStack: the stack trace changed on the second %%+v")
+	}
+
+	if got, want := fmt.Sprintf("%#v", err), `errors.NewMaybeStack("lazy")`; got != want {
+		t.Errorf("NewMaybeStack %%#v: got %s, want %s", got, want)
+	}
+}
+
+func panicMaybeStack(err error) { panic(err) }
+
+func TestMaybeStackRecover(t *testing.T) {
+	lazy := NewMaybeStack("lazy")
+	err := runRecover(func() error {
+		panicMaybeStack(Annotate(lazy, "outer"))
+		return nil
+	})
+	if origin, _ := StackOrigin(GetStackTracer(err)); origin.Function() != "panicMaybeStack" {
+		t.Errorf("Recover of NewMaybeStack: stack recorded at %q, want panicMaybeStack", origin.Function())
+	}
+	if origin, _ := StackOrigin(GetStackTracer(lazy)); origin.Function() != "panicMaybeStack" {
+		t.Errorf("Recover of NewMaybeStack: the error itself has its stack recorded at %q", origin.Function())
+	}
+}
+
+func TestWrapMaybeStack(t *testing.T) {
+	if got := WrapMaybeStack(nil, "read"); got != nil {
+		t.Errorf("WrapMaybeStack(nil): got %v, want nil", got)
+	}
+
+	err := WrapMaybeStack(io.EOF, "read")
+	if err.Error() != "read: EOF" || Cause(err) != io.EOF {
+		t.Errorf("WrapMaybeStack: got %v with cause %v", err, Cause(err))
+	}
+	if got := GetStackTracer(err).StackTrace(); len(got) != 0 {
+		t.Errorf("WrapMaybeStack: got a stack trace before %%+v: %v", got)
+	}
+	if got, want := fmt.Sprintf("%#v", err), fmt.Sprintf(`errors.WrapMaybeStack(%#v, "read")`, io.EOF); got != want {
+		t.Errorf("WrapMaybeStack %%#v: got %s, want %s", got, want)
+	}
+	if got := TrimMessagePrefix(err, "re").Error(); got != "ad: EOF" {
+		t.Errorf("TrimMessagePrefix of WrapMaybeStack: got %q", got)
+	}
+
+	stacked := New("origin")
+	if got := GetStackTracers(WrapMaybeStack(stacked, "read")); len(got) != 1 || got[0] != stacked.(StackTracer) {
+		t.Errorf("WrapMaybeStack of an error with a stack: got stacks %v, want only the one of the cause", got)
+	}
+}
@@ -0,0 +1,61 @@
+package errors
+
+import (
+	"io"
+	"testing"
+)
+
+func panicWith(v interface{}) { panic(v) }
+
+func recoverPanic(v interface{}) (err error) {
+	defer Recover(&err)
+	panicWith(v)
+	return nil
+}
+
+func recoverNilDeref() (err error) {
+	defer Recover(&err)
+	var p *struct{ x int }
+	p.x++
+	return nil
+}
+
+func recoverNoPanic() (err error) {
+	defer Recover(&err)
+	return io.EOF
+}
+
+func TestRecover(t *testing.T) {
+	if err := recoverNoPanic(); err != io.EOF {
+		t.Errorf("Recover without a panic: got %v, want %v", err, io.EOF)
+	}
+
+	tests := []struct {
+		err    error
+		msg    string
+		origin string
+	}{
+		{recoverPanic("boom"), "panic: boom", "panicWith"},
+		{recoverPanic(io.EOF), "EOF", "panicWith"},
+		{recoverNilDeref(), "runtime error: invalid memory address or nil pointer dereference", "recoverNilDeref"},
+	}
+
+	for i, tt := range tests {
+		if tt.err == nil {
+			t.Errorf("test %d: got nil, want an error", i+1)
+			continue
+		}
+		if got := tt.err.Error(); got != tt.msg {
+			t.Errorf("test %d: got %q, want %q", i+1, got, tt.msg)
+		}
+		origin, _ := StackOrigin(GetStackTracer(tt.err))
+		if got := origin.Function(); got != tt.origin {
+			t.Errorf("test %d: stack starts at %q, want %q", i+1, got, tt.origin)
+		}
+	}
+
+	stacked := New("stacked")
+	if err := recoverPanic(stacked); err != stacked {
+		t.Errorf("Recover with a stack: got %v, want the error unchanged", err)
+	}
+}
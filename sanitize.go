@@ -0,0 +1,59 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+)
+
+// Sanitize returns an error for a public API boundary that has the message of err,
+// but no stack trace, no attached information such as a code,
+// and none of the internal error types of the tree.
+// The only error kept is the first of the allowed sentinel errors found in the tree as with errors.Is,
+// which becomes the cause of the result so that callers can still compare against it.
+// If err is nil, Sanitize returns nil.
+//
+//	return errors.Sanitize(err, ErrNotFound, ErrPermission)
+func Sanitize(err error, allowed ...error) error {
+	if err == nil {
+		return nil
+	}
+	s := &sanitized{msg: err.Error()}
+	for _, sentinel := range allowed {
+		if is(err, sentinel) {
+			s.sentinel = sentinel
+			break
+		}
+	}
+	return s
+}
+
+// sanitized is the result of Sanitize: a message and an optional sentinel cause.
+type sanitized struct {
+	msg      string
+	sentinel error
+}
+
+func (s *sanitized) Error() string { return s.msg }
+
+// Cause gives the allowed sentinel, or nil if there is none.
+func (s *sanitized) Cause() error  { return s.sentinel }
+func (s *sanitized) Unwrap() error { return s.sentinel }
+
+func (s *sanitized) Format(st fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if st.Flag('#') {
+			if s.sentinel == nil {
+				fmt.Fprintf(st, "errors.Sanitize(errors.NewNoStack(%q))", s.msg)
+			} else {
+				fmt.Fprintf(st, "errors.Sanitize(errors.NewNoStack(%q), %#v)", s.msg, s.sentinel)
+			}
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(st, s.msg)
+	case 'q':
+		fmt.Fprintf(st, "%q", s.msg)
+	}
+}
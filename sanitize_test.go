@@ -0,0 +1,68 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	if got := Sanitize(nil, io.EOF); got != nil {
+		t.Errorf("Sanitize(nil): got %v, want nil", got)
+	}
+
+	internal := WithCode(Annotate(Join(New("first"), Annotate(io.EOF, "second")), "load"), "internal")
+	err := Sanitize(internal, io.ErrUnexpectedEOF, io.EOF)
+
+	if got, want := err.Error(), internal.Error(); got != want {
+		t.Errorf("Sanitize: got message %q, want %q", got, want)
+	}
+	if got, want := fmt.Sprintf("%+v", err), internal.Error(); got != want {
+		t.Errorf("Sanitize %%+v: got %q, want %q", got, want)
+	}
+	if Cause(err) != io.EOF {
+		t.Errorf("Sanitize: got cause %v, want %v", Cause(err), io.EOF)
+	}
+	if HasStack(err) || len(GetStackTracers(err)) != 0 {
+		t.Errorf("Sanitize: got a stack trace, want none")
+	}
+	if _, ok := GetCode(err); ok {
+		t.Errorf("Sanitize: got a code, want none")
+	}
+	if got := ErrorCount(err); got != 1 {
+		t.Errorf("Sanitize: got %d errors in the tree, want 1", got)
+	}
+}
+
+func TestSanitizeNotAllowed(t *testing.T) {
+	err := Sanitize(Annotate(io.EOF, "read"), io.ErrUnexpectedEOF)
+	if Cause(err) != err {
+		t.Errorf("Sanitize without an allowed sentinel: got cause %v, want none", Cause(err))
+	}
+	if Find(err, func(err error) bool { return err == io.EOF }) != nil {
+		t.Errorf("Sanitize without an allowed sentinel: io.EOF is still in the tree")
+	}
+}
+
+func TestSanitizeUnwrapMethod(t *testing.T) {
+	err := Sanitize(Annotate(unwrapOnly{io.EOF}, "read"), io.EOF)
+	if Cause(err) != io.EOF {
+		t.Errorf("Sanitize through an Unwrap method: got cause %v, want %v", Cause(err), io.EOF)
+	}
+}
+
+// uncomparableError panics when compared with == as an interface value.
+type uncomparableError []string
+
+func (e uncomparableError) Error() string { return "uncomparable" }
+
+func TestSanitizeUncomparable(t *testing.T) {
+	err := Sanitize(Annotate(uncomparableError{"a"}, "read"), uncomparableError{"b"}, io.EOF)
+	if Cause(err) != err {
+		t.Errorf("Sanitize with an uncomparable sentinel: got cause %v, want none", Cause(err))
+	}
+	err = Sanitize(Annotate(io.EOF, "read"), uncomparableError{"b"}, io.EOF)
+	if Cause(err) != io.EOF {
+		t.Errorf("Sanitize after an uncomparable sentinel: got cause %v, want %v", Cause(err), io.EOF)
+	}
+}
@@ -1,6 +1,7 @@
 package errors
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"path"
@@ -21,6 +22,13 @@ type Frame uintptr
 // multiple frames may have the same PC value.
 func (f Frame) pc() uintptr { return uintptr(f) - 1 }
 
+// PC returns the program counter for this frame as recorded by runtime.Callers:
+// it is the return address of the call.
+// runtime.CallersFrames accepts it as is.
+// Subtract 1 before calling runtime.FuncForPC,
+// which is what formatting a Frame does to find the line of the call.
+func (f Frame) PC() uintptr { return uintptr(f) }
+
 // file returns the full path to the file that contains the
 // function for this Frame's pc.
 func (f Frame) file() string {
@@ -43,6 +51,43 @@ func (f Frame) line() int {
 	return line
 }
 
+// Function returns the name of the function for this Frame without the package path,
+// as printed by %n.
+func (f Frame) Function() string { return funcname(f.name()) }
+
+// File returns the full path to the source file for this Frame.
+func (f Frame) File() string { return f.file() }
+
+// Line returns the line number in the source file for this Frame.
+func (f Frame) Line() int { return f.line() }
+
+// name returns the full name of the function for this Frame's pc.
+func (f Frame) name() string {
+	fn := runtime.FuncForPC(f.pc())
+	if fn == nil {
+		return "unknown"
+	}
+	return fn.Name()
+}
+
+var sourcePrefixTrim = ""
+
+// SetSourcePrefixTrim sets a prefix to remove from the source file paths of printed frames,
+// such as the directory of the module.
+// This keeps build directories out of stack traces and makes them shorter.
+// Paths that do not start with the prefix are printed in full.
+// This should be called during program initialization.
+func SetSourcePrefixTrim(prefix string) {
+	sourcePrefixTrim = prefix
+}
+
+func trimSourcePrefix(file string) string {
+	if sourcePrefixTrim == "" {
+		return file
+	}
+	return strings.TrimPrefix(file, sourcePrefixTrim)
+}
+
 // Format formats the frame according to the fmt.Formatter interface.
 //
 //    %s    source file
@@ -54,6 +99,7 @@ func (f Frame) line() int {
 //
 //    %+s   function name and path of source file relative to the compile time
 //          GOPATH separated by \n\t (<funcname>\n\t<path>)
+//          The prefix set with SetSourcePrefixTrim is removed from the path.
 //    %+v   equivalent to %+s:%d
 func (f Frame) Format(s fmt.State, verb rune) {
 	switch verb {
@@ -66,7 +112,7 @@ func (f Frame) Format(s fmt.State, verb rune) {
 				io.WriteString(s, "unknown")
 			} else {
 				file, _ := fn.FileLine(pc)
-				fmt.Fprintf(s, "%s\n\t%s", fn.Name(), file)
+				fmt.Fprintf(s, "%s\n\t%s", fn.Name(), trimSourcePrefix(file))
 			}
 		default:
 			io.WriteString(s, path.Base(f.file()))
@@ -94,14 +140,14 @@ type StackTrace []Frame
 // Format accepts flags that alter the printing of some verbs, as follows:
 //
 //    %+v   Prints filename, function, and line number for each Frame in the stack.
+//          Frames rejected by the filter set with SetFrameFilter are skipped,
+//          and the number of frames printed is limited by SetMaxPrintFrames.
 func (st StackTrace) Format(s fmt.State, verb rune) {
 	switch verb {
 	case 'v':
 		switch {
 		case s.Flag('+'):
-			for _, f := range st {
-				fmt.Fprintf(s, "\n%+v", f)
-			}
+			st.formatFrames(s)
 		case s.Flag('#'):
 			fmt.Fprintf(s, "%#v", []Frame(st))
 		default:
@@ -112,6 +158,220 @@ func (st StackTrace) Format(s fmt.State, verb rune) {
 	}
 }
 
+// frameJSON is the JSON representation of a Frame.
+type frameJSON struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// MarshalJSON gives an array with an object for each Frame
+// with the function name (as printed by %n), the full file path, and the line:
+//
+//    [{"func":"main","file":"/home/user/src/main.go","line":12}]
+func (st StackTrace) MarshalJSON() ([]byte, error) {
+	frames := make([]frameJSON, len(st))
+	for i, f := range st {
+		frames[i] = frameJSON{
+			Func: funcname(f.name()),
+			File: f.file(),
+			Line: f.line(),
+		}
+	}
+	return json.Marshal(frames)
+}
+
+// formatFrames writes each Frame in the %+v format, skipping frames rejected by the frame filter.
+// After printing the maximum number of frames, the number of remaining frames is printed instead.
+func (st StackTrace) formatFrames(s fmt.State) {
+	if frameFilter != nil {
+		visible := make(StackTrace, 0, len(st))
+		for _, f := range st {
+			if frameFilter(f.name(), f.file()) {
+				visible = append(visible, f)
+			}
+		}
+		st = visible
+	}
+	st, more := limitFrames(st, maxPrintFrames)
+	for _, f := range st {
+		fmt.Fprintf(s, "\n%+v", f)
+	}
+	if more > 0 {
+		fmt.Fprintf(s, "\n... (%d more frames)", more)
+	}
+}
+
+// limitFrames gives the first max frames to print and the number of frames left out.
+// A max less than 1 keeps all frames.
+func limitFrames(st StackTrace, max int) (StackTrace, int) {
+	if max < 1 || len(st) <= max {
+		return st, 0
+	}
+	return st[:max], len(st) - max
+}
+
+var maxPrintFrames = 0
+
+// SetMaxPrintFrames limits how many frames of a stack trace are printed by %+v.
+// When a stack trace has more frames, a line with the number of frames left out is printed instead:
+//
+//    ... (12 more frames)
+//
+// This does not change how many frames are recorded (see SetStackDepth),
+// so StackTrace() still returns all of them.
+// A value less than 1, the default, prints all frames.
+// This should be called during program initialization.
+func SetMaxPrintFrames(n int) {
+	maxPrintFrames = n
+}
+
+var frameFilter func(funcName, file string) bool
+
+// SetFrameFilter sets a function that decides which frames are printed.
+// It is given the full function name and file path of each frame,
+// and the frame is skipped when it returns false.
+// This can hide frames anywhere in the stack trace, such as generated code.
+// The filter only applies to the verbose %+v output of a StackTrace or an error.
+// A nil filter, the default, prints all frames.
+// This should be called during program initialization.
+func SetFrameFilter(filter func(funcName, file string) bool) {
+	frameFilter = filter
+}
+
+// StackOrigin gives the top Frame of the stack trace,
+// which is the call site where the stack was recorded.
+// It reports false when there is no stack trace.
+func StackOrigin(st StackTracer) (Frame, bool) {
+	if st == nil {
+		return 0, false
+	}
+	trace := st.StackTrace()
+	if len(trace) == 0 {
+		return 0, false
+	}
+	return trace[0], true
+}
+
+// OriginFrames gives the distinct frames where the leaf errors of the tree originated,
+// in the order of WalkDeep.
+// For each chain that does not end in a group,
+// the origin is the first frame of the innermost stack trace in that chain,
+// so the frames of wrappers are not included.
+// This summarizes the call sites that failed in a group of errors.
+func OriginFrames(err error) []Frame {
+	var frames []Frame
+	originFrames(err, &frames)
+	return frames
+}
+
+func originFrames(err error, frames *[]Frame) {
+	var innermost StackTracer
+	hasMembers := false
+	for unErr := err; unErr != nil; unErr = unwrapAny(unErr) {
+		if st, ok := unErr.(StackTracer); ok && len(st.StackTrace()) > 0 {
+			innermost = st
+		}
+		for _, member := range groupErrors(unErr) {
+			if member != nil {
+				hasMembers = true
+				originFrames(member, frames)
+			}
+		}
+	}
+	if hasMembers || innermost == nil {
+		return
+	}
+	origin, _ := StackOrigin(innermost)
+	for _, f := range *frames {
+		if f == origin {
+			return
+		}
+	}
+	*frames = append(*frames, origin)
+}
+
+var labelStackOrigin = false
+
+// SetStackOriginLabel controls whether %+v output of an error labels
+// each stack trace with the function that recorded it, for example:
+//
+//    stack recorded at: pkg.function
+//
+// This helps tell stacks apart when multiple stacks are printed for one error.
+// It is off by default.
+// This should be called during program initialization.
+func SetStackOriginLabel(enabled bool) {
+	labelStackOrigin = enabled
+}
+
+// TrimRuntime removes the frames of the runtime and testing packages
+// from the outermost end of the stack trace, such as runtime.goexit and testing.tRunner.
+// Frames of these packages that are followed by other frames are kept.
+func TrimRuntime(st StackTrace) StackTrace {
+	end := len(st)
+	for end > 0 {
+		name := st[end-1].name()
+		if !strings.HasPrefix(name, "runtime.") && !strings.HasPrefix(name, "testing.") {
+			break
+		}
+		end--
+	}
+	return st[:end]
+}
+
+// StackFormatOptions controls the output of FormatStack.
+// The zero value formats like %+v without the global settings.
+type StackFormatOptions struct {
+	// OmitFiles prints only the function name and line of each frame,
+	// as "function:line", instead of the function name and the file path on its own line.
+	OmitFiles bool
+	// TrimRuntime removes the outermost runtime and testing frames, as with TrimRuntime.
+	TrimRuntime bool
+	// MaxFrames limits how many frames are printed, as with SetMaxPrintFrames.
+	// A value less than 1 prints all frames.
+	MaxFrames int
+}
+
+// FormatStack gives the frames of the stack trace, one per line.
+// By default each frame is formatted as with %+v:
+//
+//    pkg.function
+//    	/path/to/file.go:12
+//
+// The file path has the prefix set with SetSourcePrefixTrim removed.
+// Unlike %+v, the frame filter set with SetFrameFilter is not applied,
+// and there is no leading newline.
+func FormatStack(st StackTrace, opts StackFormatOptions) string {
+	if opts.TrimRuntime {
+		st = TrimRuntime(st)
+	}
+	st, more := limitFrames(st, opts.MaxFrames)
+	lines := make([]string, 0, len(st)+1)
+	for _, f := range st {
+		if opts.OmitFiles {
+			lines = append(lines, fmt.Sprintf("%s:%d", f.name(), f.line()))
+		} else {
+			lines = append(lines, fmt.Sprintf("%+v", f))
+		}
+	}
+	if more > 0 {
+		lines = append(lines, fmt.Sprintf("... (%d more frames)", more))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// PCs returns the program counter of each Frame, as given by Frame.PC.
+// This allows symbolizing the stack with runtime.CallersFrames or other tools,
+// with the same lines as formatting the StackTrace.
+func (st StackTrace) PCs() []uintptr {
+	pcs := make([]uintptr, len(st))
+	for i, f := range st {
+		pcs[i] = f.PC()
+	}
+	return pcs
+}
+
 // stack represents a stack of program counters.
 type stack []uintptr
 
@@ -120,10 +380,10 @@ func (s *stack) Format(st fmt.State, verb rune) {
 	case 'v':
 		switch {
 		case st.Flag('+'):
-			for _, pc := range *s {
-				f := Frame(pc)
-				fmt.Fprintf(st, "\n%+v", f)
+			if origin, ok := StackOrigin(s); ok && labelStackOrigin {
+				fmt.Fprintf(st, "\nstack recorded at: %n", origin)
 			}
+			s.StackTrace().formatFrames(st)
 		}
 	}
 }
@@ -136,16 +396,67 @@ func (s *stack) StackTrace() StackTrace {
 	return f
 }
 
+// SameStack tells whether two errors recorded the same stack trace:
+// both have the same program counters.
+// The top frames are compared first, so different stacks usually return quickly.
+// This is useful to de-duplicate stack traces in logs,
+// as GetStackTracers does for an error that was wrapped multiple times.
+// A nil StackTracer is only the same as another nil.
+func SameStack(a, b StackTracer) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	aTrace, bTrace := a.StackTrace(), b.StackTrace()
+	if len(aTrace) != len(bTrace) {
+		return false
+	}
+	for i := range aTrace {
+		if aTrace[i] != bTrace[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func callers() *stack {
 	return callersSkip(4)
 }
 
+const defaultStackDepth = 32
+
+var stackDepth = defaultStackDepth
+
+// SetStackDepth sets the maximum number of frames recorded for a stack trace.
+// The default is 32, which can cut off the outermost callers of deeply recursive code.
+// A depth less than 1 records the whole stack.
+// This should be called during program initialization.
+func SetStackDepth(depth int) {
+	stackDepth = depth
+}
+
 func callersSkip(skip int) *stack {
-	const depth = 32
-	var pcs [depth]uintptr
-	n := runtime.Callers(skip, pcs[:])
-	var st stack = pcs[0:n]
-	return &st
+	return callersDepth(skip+1, stackDepth)
+}
+
+// callersDepth records up to depth frames, or the whole stack if depth is less than 1.
+// The buffer grows until runtime.Callers does not fill it.
+func callersDepth(skip, depth int) *stack {
+	size := defaultStackDepth
+	if depth > 0 && depth < size {
+		size = depth
+	}
+	for {
+		pcs := make([]uintptr, size)
+		n := runtime.Callers(skip, pcs)
+		if n < size || size == depth {
+			var st stack = pcs[0:n]
+			return &st
+		}
+		size *= 2
+		if depth > 0 && size > depth {
+			size = depth
+		}
+	}
 }
 
 // funcname removes the path prefix component of a function's name reported by func.Name().
@@ -168,3 +479,10 @@ func funcname(name string) string {
 func NewStack(skip int) StackTracer {
 	return callersSkip(skip + 3)
 }
+
+// NewStackDepth is like NewStack, but records up to depth frames
+// instead of the depth set by SetStackDepth.
+// A depth less than 1 records the whole stack.
+func NewStackDepth(skip, depth int) StackTracer {
+	return callersDepth(skip+3, depth)
+}
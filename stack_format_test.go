@@ -0,0 +1,353 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func stackOriginInner() error { return New("inner") }
+
+func stackOriginOuter() error { return WithStack(stackOriginInner()) }
+
+func TestStackOrigin(t *testing.T) {
+	if _, ok := StackOrigin(nil); ok {
+		t.Errorf("StackOrigin(nil): got ok, want not ok")
+	}
+	origin, ok := StackOrigin(GetStackTracer(stackOriginInner()))
+	if !ok {
+		t.Fatalf("StackOrigin: got not ok, want ok")
+	}
+	if got, want := fmt.Sprintf("%n", origin), "stackOriginInner"; got != want {
+		t.Errorf("StackOrigin: got %q, want %q", got, want)
+	}
+}
+
+func TestSetStackOriginLabel(t *testing.T) {
+	err := stackOriginOuter()
+	if got := fmt.Sprintf("%+v", err); strings.Contains(got, "stack recorded at") {
+		t.Errorf("%%+v without labels: got labels in %q", got)
+	}
+
+	SetStackOriginLabel(true)
+	defer SetStackOriginLabel(false)
+	got := fmt.Sprintf("%+v", err)
+	for _, want := range []string{
+		"inner\nstack recorded at: stackOriginInner\n",
+		"\nstack recorded at: stackOriginOuter\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%%+v with labels: %q not found in %q", want, got)
+		}
+	}
+}
+
+func recurseStack(n int, record func() StackTracer) StackTracer {
+	if n == 0 {
+		return record()
+	}
+	return recurseStack(n-1, record)
+}
+
+func TestNewStackDepth(t *testing.T) {
+	deep := func(depth int) StackTrace {
+		return recurseStack(100, func() StackTracer { return NewStackDepth(1, depth) }).StackTrace()
+	}
+
+	if got := len(deep(10)); got != 10 {
+		t.Errorf("NewStackDepth(1, 10): got %d frames, want 10", got)
+	}
+	if got := len(deep(64)); got != 64 {
+		t.Errorf("NewStackDepth(1, 64): got %d frames, want 64", got)
+	}
+	full := deep(0)
+	if len(full) <= 100 {
+		t.Errorf("NewStackDepth(1, 0): got %d frames, want more than 100", len(full))
+	}
+	if got := fmt.Sprintf("%n", full[len(full)-1]); got != "goexit" {
+		t.Errorf("NewStackDepth(1, 0): outermost frame is %q, want goexit", got)
+	}
+
+	got := NewStackDepth(0, 0).StackTrace()
+	want := NewStack(0).StackTrace()
+	if fmt.Sprintf("%n", got[0]) != fmt.Sprintf("%n", want[0]) {
+		t.Errorf("NewStackDepth(0, 0): top frame %n, want %n", got[0], want[0])
+	}
+}
+
+func TestSetStackDepth(t *testing.T) {
+	record := func() StackTracer { return GetStackTracer(New("deep")) }
+	if got := len(recurseStack(100, record).StackTrace()); got != 32 {
+		t.Errorf("default stack depth: got %d frames, want 32", got)
+	}
+
+	SetStackDepth(128)
+	defer SetStackDepth(defaultStackDepth)
+	if got := len(recurseStack(200, record).StackTrace()); got != 128 {
+		t.Errorf("SetStackDepth(128): got %d frames, want 128", got)
+	}
+}
+
+func TestStackTracePCs(t *testing.T) {
+	st := NewStack(0).StackTrace()
+	pcs := st.PCs()
+	if len(pcs) != len(st) {
+		t.Fatalf("PCs: got %d program counters, want %d", len(pcs), len(st))
+	}
+
+	frames := runtime.CallersFrames(pcs)
+	for i, f := range st {
+		if pcs[i] != f.PC() {
+			t.Errorf("PCs()[%d]: got %v, want %v", i, pcs[i], f.PC())
+		}
+		fn := runtime.FuncForPC(f.PC() - 1)
+		if got, want := fn.Name(), fmt.Sprintf("%+s", f); !strings.HasPrefix(want, got) {
+			t.Errorf("FuncForPC(Frame.PC() - 1): got %q, want a prefix of %q", got, want)
+		}
+		frame, _ := frames.Next()
+		if got, want := fmt.Sprintf("%d", frame.Line), fmt.Sprintf("%d", f); got != want {
+			t.Errorf("CallersFrames line for frame %d: got %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestTrimRuntime(t *testing.T) {
+	st := NewStack(0).StackTrace()
+	trimmed := TrimRuntime(st)
+	if len(trimmed) != 1 {
+		t.Fatalf("TrimRuntime: got %d frames, want 1: %+v", len(trimmed), trimmed)
+	}
+	if got := fmt.Sprintf("%n", trimmed[0]); got != "TestTrimRuntime" {
+		t.Errorf("TrimRuntime: got %q, want TestTrimRuntime", got)
+	}
+	if len(st) <= len(trimmed) {
+		t.Errorf("TrimRuntime modified the original stack: %+v", st)
+	}
+
+	// runtime frames in the middle are kept
+	middle := append(StackTrace{st[len(st)-1]}, trimmed...)
+	if got := TrimRuntime(middle); len(got) != 2 {
+		t.Errorf("TrimRuntime: got %d frames, want 2: %+v", len(got), got)
+	}
+
+	if got := TrimRuntime(nil); len(got) != 0 {
+		t.Errorf("TrimRuntime(nil): got %v", got)
+	}
+}
+
+func TestSetFrameFilter(t *testing.T) {
+	err := stackOriginOuter()
+	st := GetStackTracer(err).StackTrace()
+
+	SetFrameFilter(func(funcName, file string) bool {
+		return !strings.HasPrefix(funcName, "testing.") && !strings.HasPrefix(funcName, "runtime.")
+	})
+	defer SetFrameFilter(nil)
+
+	for _, formatted := range []string{fmt.Sprintf("%+v", err), fmt.Sprintf("%+v", st)} {
+		if strings.Contains(formatted, "testing.tRunner") || strings.Contains(formatted, "runtime.goexit") {
+			t.Errorf("filtered frames printed: %s", formatted)
+		}
+		if !strings.Contains(formatted, "TestSetFrameFilter\n\t") {
+			t.Errorf("unfiltered frame not printed: %s", formatted)
+		}
+	}
+	if got := len(st); got < 4 {
+		t.Errorf("SetFrameFilter changed the StackTrace: got %d frames", got)
+	}
+}
+
+func TestStackTraceMarshalJSON(t *testing.T) {
+	st := stackOriginInner().(StackTracer).StackTrace()[:2]
+	data, err := json.Marshal(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var frames []struct {
+		Func string
+		File string
+		Line int
+	}
+	if err := json.Unmarshal(data, &frames); err != nil {
+		t.Fatalf("StackTrace.MarshalJSON: %v in %s", err, data)
+	}
+	if len(frames) != 2 {
+		t.Fatalf("StackTrace.MarshalJSON: got %d frames, want 2: %s", len(frames), data)
+	}
+	for i, f := range st {
+		want := frames[i]
+		if want.Func != fmt.Sprintf("%n", f) || want.Line != f.line() || want.File != f.file() {
+			t.Errorf("StackTrace.MarshalJSON frame %d: got %+v, want %n %s:%d", i, want, f, f.file(), f.line())
+		}
+	}
+	if frames[0].Func != "stackOriginInner" || !strings.HasSuffix(frames[0].File, "/stack_format_test.go") {
+		t.Errorf("StackTrace.MarshalJSON: got %+v", frames[0])
+	}
+}
+
+func TestSetSourcePrefixTrim(t *testing.T) {
+	f := stackOriginInner().(StackTracer).StackTrace()[0]
+	file := f.file()
+	dir := file[:strings.LastIndex(file, "/")+1]
+
+	SetSourcePrefixTrim(dir)
+	defer SetSourcePrefixTrim("")
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"%s", "stack_format_test.go"},
+		{"%+s", "github.com/pkg/errors.stackOriginInner\n\tstack_format_test.go"},
+		{"%+v", fmt.Sprintf("github.com/pkg/errors.stackOriginInner\n\tstack_format_test.go:%d", f.line())},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprintf(tt.format, f); got != tt.want {
+			t.Errorf("%s with prefix trimmed: got %q, want %q", tt.format, got, tt.want)
+		}
+	}
+
+	SetSourcePrefixTrim("/no/such/prefix/")
+	if got, want := fmt.Sprintf("%+s", f), "github.com/pkg/errors.stackOriginInner\n\t"+file; got != want {
+		t.Errorf("%%+s without a matching prefix: got %q, want %q", got, want)
+	}
+}
+
+func TestSetMaxPrintFrames(t *testing.T) {
+	st := recurseStack(10, func() StackTracer { return NewStack(0) }).StackTrace()
+	if len(st) < 12 {
+		t.Fatalf("need a deeper stack, got %d frames", len(st))
+	}
+
+	SetMaxPrintFrames(3)
+	defer SetMaxPrintFrames(0)
+	got := fmt.Sprintf("%+v", st)
+	want := fmt.Sprintf("\n%+v\n%+v\n%+v\n... (%d more frames)", st[0], st[1], st[2], len(st)-3)
+	if got != want {
+		t.Errorf("SetMaxPrintFrames(3): got %q, want %q", got, want)
+	}
+	if got := fmt.Sprintf("%+v", st[:3]); strings.Contains(got, "more frames") {
+		t.Errorf("SetMaxPrintFrames(3) with 3 frames: got %q", got)
+	}
+	if got := len(st); got < 12 {
+		t.Errorf("SetMaxPrintFrames changed the StackTrace: got %d frames", got)
+	}
+
+	err := stackOriginInner()
+	if got := fmt.Sprintf("%+v", err); !strings.HasSuffix(got, "more frames)") || strings.Count(got, "\n\t") != 3 {
+		t.Errorf("SetMaxPrintFrames(3) for an error: got %q", got)
+	}
+
+	// Frames hidden by the filter are not counted as left out.
+	SetFrameFilter(func(funcName, file string) bool { return !strings.HasPrefix(funcName, "runtime.") })
+	defer SetFrameFilter(nil)
+	visible := 0
+	for _, f := range st {
+		if !strings.HasPrefix(f.name(), "runtime.") {
+			visible++
+		}
+	}
+	got = fmt.Sprintf("%+v", st)
+	if want := fmt.Sprintf("... (%d more frames)", visible-3); !strings.HasSuffix(got, want) {
+		t.Errorf("SetMaxPrintFrames(3) with a filter: got %q, want suffix %q", got, want)
+	}
+}
+
+func TestFrameAccessors(t *testing.T) {
+	f := stackOriginInner().(StackTracer).StackTrace()[0]
+	if got := f.Function(); got != "stackOriginInner" {
+		t.Errorf("Frame.Function(): got %q, want %q", got, "stackOriginInner")
+	}
+	if got := f.File(); !strings.HasSuffix(got, "/github.com/pkg/errors/stack_format_test.go") {
+		t.Errorf("Frame.File(): got %q", got)
+	}
+	if got, want := fmt.Sprint(f.Line()), fmt.Sprintf("%d", f); got != want {
+		t.Errorf("Frame.Line(): got %s, want %s", got, want)
+	}
+
+	var unknown Frame
+	if got := unknown.Function(); got != "unknown" {
+		t.Errorf("Frame(0).Function(): got %q, want %q", got, "unknown")
+	}
+}
+
+func originSiteA(i int) error { return Annotatef(fmt.Errorf("item %d", i), "site a") }
+func originSiteB() error      { return New("site b") }
+
+func TestOriginFrames(t *testing.T) {
+	var errs []error
+	for i := 0; i < 3; i++ {
+		errs = append(errs, originSiteA(i), originSiteB())
+	}
+	err := Annotate(Join(errs...), "batch")
+
+	frames := OriginFrames(err)
+	var got []string
+	for _, f := range frames {
+		got = append(got, f.Function())
+	}
+	if want := []string{"originSiteA", "originSiteB"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("OriginFrames: got %v, want %v", got, want)
+	}
+
+	if frames := OriginFrames(Annotate(stackOriginInner(), "outer")); len(frames) != 1 || frames[0].Function() != "stackOriginInner" {
+		t.Errorf("OriginFrames of a chain: got %v, want the innermost stack", frames)
+	}
+	if frames := OriginFrames(fmt.Errorf("no stack")); frames != nil {
+		t.Errorf("OriginFrames without a stack: got %v, want nil", frames)
+	}
+}
+
+func TestSameStack(t *testing.T) {
+	inner := stackOriginInner().(StackTracer)
+	again := stackOriginInner().(StackTracer)
+	other := New("other").(StackTracer)
+
+	copied := &fundamental{msg: "copy", stack: inner.(*fundamental).stack}
+	if !SameStack(inner, inner) || !SameStack(inner, copied) {
+		t.Errorf("SameStack of the same stack: got false, want true")
+	}
+	if SameStack(inner, again) {
+		t.Errorf("SameStack of stacks recorded from different lines: got true, want false")
+	}
+	if SameStack(inner, other) {
+		t.Errorf("SameStack of different stacks: got true, want false")
+	}
+	if SameStack(inner, nil) || SameStack(nil, inner) || !SameStack(nil, nil) {
+		t.Errorf("SameStack with nil: got the wrong result")
+	}
+}
+
+func TestFormatStack(t *testing.T) {
+	st := stackOriginInner().(StackTracer).StackTrace()
+
+	got := FormatStack(st, StackFormatOptions{})
+	if want := strings.TrimPrefix(fmt.Sprintf("%+v", st), "\n"); got != want {
+		t.Errorf("FormatStack: got %q, want %q", got, want)
+	}
+
+	got = FormatStack(st, StackFormatOptions{OmitFiles: true, TrimRuntime: true})
+	want := fmt.Sprintf("github.com/pkg/errors.stackOriginInner:%d\ngithub.com/pkg/errors.TestFormatStack:%d", st[0].Line(), st[1].Line())
+	if got != want {
+		t.Errorf("FormatStack without files: got %q, want %q", got, want)
+	}
+
+	got = FormatStack(st, StackFormatOptions{OmitFiles: true, MaxFrames: 1})
+	want = fmt.Sprintf("github.com/pkg/errors.stackOriginInner:%d\n... (%d more frames)", st[0].Line(), len(st)-1)
+	if got != want {
+		t.Errorf("FormatStack with MaxFrames: got %q, want %q", got, want)
+	}
+
+	// Frames removed by TrimRuntime are not counted as left out.
+	got = FormatStack(st, StackFormatOptions{OmitFiles: true, TrimRuntime: true, MaxFrames: 1})
+	want = fmt.Sprintf("github.com/pkg/errors.stackOriginInner:%d\n... (%d more frames)", st[0].Line(), len(TrimRuntime(st))-1)
+	if got != want {
+		t.Errorf("FormatStack with TrimRuntime and MaxFrames: got %q, want %q", got, want)
+	}
+
+	if got := FormatStack(nil, StackFormatOptions{}); got != "" {
+		t.Errorf("FormatStack(nil): got %q, want empty", got)
+	}
+}
@@ -0,0 +1,20 @@
+package errors
+
+var httpStatusKind = &markerKind{name: "http_status", constructor: "WithHTTPStatus"}
+
+// WithHTTPStatus attaches an HTTP status code to err
+// for an HTTP handler to respond with.
+func WithHTTPStatus(err error, status int) error {
+	return mark(err, httpStatusKind, status)
+}
+
+// HTTPStatus gives the status attached with WithHTTPStatus.
+// It reports false when there is no status,
+// in which case the caller decides the default, usually 500.
+func HTTPStatus(err error) (int, bool) {
+	status, ok := markerValue(err, httpStatusKind)
+	if !ok {
+		return 0, false
+	}
+	return status.(int), true
+}
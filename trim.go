@@ -0,0 +1,56 @@
+package errors
+
+import "strings"
+
+// TrimMessagePrefix returns an equivalent error with prefix removed
+// from the start of the message added by each error in the tree,
+// for example a package name that an upstream library puts in front of all of its messages.
+// The errors of this package are copied with their stack traces and other information,
+// and errors of other packages are kept as they are.
+func TrimMessagePrefix(err error, prefix string) error {
+	if err == nil || prefix == "" {
+		return err
+	}
+	switch e := err.(type) {
+	case *fundamental:
+		return &fundamental{msg: strings.TrimPrefix(e.msg, prefix), stack: e.stack}
+	case *fundamentalNoStack:
+		return &fundamentalNoStack{msg: strings.TrimPrefix(e.msg, prefix)}
+	case *withMessage:
+		return &withMessage{
+			cause:         TrimMessagePrefix(e.cause, prefix),
+			msg:           strings.TrimPrefix(e.msg, prefix),
+			causeHasStack: e.causeHasStack,
+		}
+	case *wrappedf:
+		cause := TrimMessagePrefix(e.cause, prefix)
+		msg := strings.TrimPrefix(e.msg, prefix)
+		if local := strings.TrimSuffix(msg, e.cause.Error()); len(local) < len(msg) {
+			msg = local + cause.Error()
+		}
+		return &wrappedf{msg: msg, cause: cause}
+	case *withStack:
+		return &withStack{TrimMessagePrefix(e.error, prefix), e.stack}
+	case *maybeStack:
+		return &maybeStack{error: TrimMessagePrefix(e.error, prefix), stack: e.recorded()}
+	case *errorGroup:
+		return &errorGroup{errs: trimGroup(e.errs, prefix)}
+	case *ErrorList:
+		return &ErrorList{errorGroup{errs: trimGroup(e.errs, prefix)}}
+	case *marker:
+		trimmed := *e
+		trimmed.cause = TrimMessagePrefix(e.cause, prefix)
+		return &trimmed
+	case *sanitized:
+		return &sanitized{msg: strings.TrimPrefix(e.msg, prefix), sentinel: e.sentinel}
+	}
+	return err
+}
+
+func trimGroup(errs []error, prefix string) []error {
+	trimmed := make([]error, len(errs))
+	for i, err := range errs {
+		trimmed[i] = TrimMessagePrefix(err, prefix)
+	}
+	return trimmed
+}
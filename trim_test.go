@@ -0,0 +1,88 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestTrimMessagePrefix(t *testing.T) {
+	inner := New("myservice: connection refused")
+	err := WithCode(Annotate(WithMessage(inner, "myservice: dial"), "myservice: query"), "db")
+
+	trimmed := TrimMessagePrefix(err, "myservice: ")
+	if got, want := trimmed.Error(), "query: dial: connection refused"; got != want {
+		t.Errorf("TrimMessagePrefix: got %q, want %q", got, want)
+	}
+	if got, want := err.Error(), "myservice: query: myservice: dial: myservice: connection refused"; got != want {
+		t.Errorf("TrimMessagePrefix changed the original error: got %q, want %q", got, want)
+	}
+	if code, _ := GetCode(trimmed); code != "db" {
+		t.Errorf("TrimMessagePrefix: got code %q, want db", code)
+	}
+	stacks := GetStackTracers(trimmed)
+	if len(stacks) != 1 || !SameStack(stacks[0], inner.(StackTracer)) {
+		t.Errorf("TrimMessagePrefix: got stacks %v, want the original stack", stacks)
+	}
+	if got, want := fmt.Sprintf("%+v", trimmed), fmt.Sprintf("%+v", err); len(got) >= len(want) {
+		t.Errorf("TrimMessagePrefix %%+v: got %q, want it shorter than %q", got, want)
+	}
+}
+
+func TestTrimMessagePrefixOther(t *testing.T) {
+	if got := TrimMessagePrefix(nil, "x"); got != nil {
+		t.Errorf("TrimMessagePrefix(nil): got %v, want nil", got)
+	}
+
+	foreign := errors.New("myservice: foreign")
+	if got := TrimMessagePrefix(foreign, "myservice: "); got != foreign {
+		t.Errorf("TrimMessagePrefix of a foreign error: got %v, want it unchanged", got)
+	}
+
+	group := Join(NewNoStack("myservice: a"), WithMessage(foreign, "myservice: b"))
+	if got, want := TrimMessagePrefix(group, "myservice: ").Error(), "a\nb: myservice: foreign"; got != want {
+		t.Errorf("TrimMessagePrefix of a group: got %q, want %q", got, want)
+	}
+}
+
+func TestTrimMessagePrefixTypes(t *testing.T) {
+	list := &ErrorList{}
+	list.Add(NewNoStack("p: a"))
+	list.Add(WithMessage(io.EOF, "p: b"))
+
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{New("p: a"), "a"},
+		{NewNoStack("p: a"), "a"},
+		{WithMessage(io.EOF, "p: read"), "read: EOF"},
+		{AddStack(NewNoStack("p: a")), "a"},
+		{&wrappedf{msg: "p: read: p: a", cause: NewNoStack("p: a")}, "read: a"},
+		{&wrappedf{msg: "p: read (p: a)", cause: NewNoStack("p: a")}, "read (p: a)"},
+		{Join(NewNoStack("p: a"), NewNoStack("p: b")), "a\nb"},
+		{list, "a\nb: EOF"},
+		{WithCode(NewNoStack("p: a"), "E1"), "a"},
+		{NewMaybeStack("p: a"), "a"},
+		{Sanitize(NewNoStack("p: a")), "a"},
+	}
+	for i, tt := range tests {
+		trimmed := TrimMessagePrefix(tt.err, "p: ")
+		if got := trimmed.Error(); got != tt.want {
+			t.Errorf("test %d: TrimMessagePrefix of %T: got %q, want %q", i+1, tt.err, got, tt.want)
+		}
+		if reflect.TypeOf(trimmed) != reflect.TypeOf(tt.err) {
+			t.Errorf("test %d: TrimMessagePrefix of %T: got %T", i+1, tt.err, trimmed)
+		}
+	}
+
+	if code, _ := GetCode(TrimMessagePrefix(WithCode(NewNoStack("p: a"), "E1"), "p: ")); code != "E1" {
+		t.Errorf("TrimMessagePrefix: got code %q, want E1", code)
+	}
+	sentinel := NewNoStack("p: sentinel")
+	if Cause(TrimMessagePrefix(Sanitize(Annotate(sentinel, "p: read"), sentinel), "p: ")) != sentinel {
+		t.Errorf("TrimMessagePrefix of Sanitize: lost the allowed sentinel")
+	}
+}
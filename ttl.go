@@ -0,0 +1,30 @@
+package errors
+
+import "time"
+
+// now is the time source of WithTTL and IsStale, replaced in tests.
+var now = time.Now
+
+var ttlKind = &markerKind{name: "ttl", constructor: "WithTTL"}
+
+// ttl is the value attached by WithTTL.
+type ttl struct {
+	duration time.Duration
+	expires  time.Time
+}
+
+// WithTTL marks err as valid for the duration d from now,
+// for example when a failure is cached and should be retried once it is stale.
+func WithTTL(err error, d time.Duration) error {
+	return mark(err, ttlKind, ttl{duration: d, expires: now().Add(d)})
+}
+
+// IsStale tells whether the TTL given with WithTTL has elapsed.
+// An error without a TTL is never stale.
+func IsStale(err error) bool {
+	value, ok := markerValue(err, ttlKind)
+	if !ok {
+		return false
+	}
+	return !now().Before(value.(ttl).expires)
+}
@@ -0,0 +1,39 @@
+package errors
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestWithTTL(t *testing.T) {
+	clock := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	err := Annotate(WithTTL(io.EOF, time.Minute), "read")
+	tests := []struct {
+		elapsed time.Duration
+		want    bool
+	}{
+		{0, false},
+		{59 * time.Second, false},
+		{time.Minute, true},
+		{time.Hour, true},
+	}
+	start := clock
+	for _, tt := range tests {
+		clock = start.Add(tt.elapsed)
+		if got := IsStale(err); got != tt.want {
+			t.Errorf("IsStale after %v: got %v, want %v", tt.elapsed, got, tt.want)
+		}
+	}
+
+	clock = start.Add(time.Hour)
+	if IsStale(io.EOF) {
+		t.Errorf("IsStale without a TTL: got true, want false")
+	}
+	if IsStale(WithTTL(err, time.Minute)) {
+		t.Errorf("IsStale: got true, want the outermost TTL to be used")
+	}
+}
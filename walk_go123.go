@@ -0,0 +1,39 @@
+//go:build go1.23
+// +build go1.23
+
+package errors
+
+import "iter"
+
+// WithStacks is an iterator version of WalkStacks.
+// It yields each error of the tree paired with the StackTrace it recorded,
+// which is empty when that error did not record a stack.
+func WithStacks(err error) iter.Seq2[error, StackTrace] {
+	return func(yield func(error, StackTrace) bool) {
+		WalkStacks(err, func(err error, st StackTrace) bool {
+			return !yield(err, st)
+		})
+	}
+}
+
+// UnwrapGroupsPath is an iterator version of WalkDeepPath.
+// It yields each error of the tree with its position in the tree.
+func UnwrapGroupsPath(err error) iter.Seq2[[]int, error] {
+	return func(yield func([]int, error) bool) {
+		WalkDeepPath(err, func(path []int, err error) bool {
+			return !yield(path, err)
+		})
+	}
+}
+
+// UnwrapGroupsLevel is like UnwrapGroupsPath, but yields the level of each error in the tree:
+// how many times it was unwrapped from the top-level error,
+// where going into a member of a group counts as one level.
+// This is useful for indenting nested errors.
+func UnwrapGroupsLevel(err error) iter.Seq2[int, error] {
+	return func(yield func(int, error) bool) {
+		walkTree(nil, 0, err, func(_ []int, level int, err error) bool {
+			return !yield(level, err)
+		})
+	}
+}
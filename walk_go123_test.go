@@ -0,0 +1,178 @@
+//go:build go1.23
+// +build go1.23
+
+package errors
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWithStacks(t *testing.T) {
+	err := WithStack(WithMessage(io.EOF, "read"))
+	var layers, stacks int
+	for e, st := range WithStacks(err) {
+		layers++
+		if len(st) > 0 {
+			stacks++
+			if e != err {
+				t.Errorf("WithStacks: stack paired with %v, want %v", e, err)
+			}
+		}
+	}
+	if layers != 3 || stacks != 1 {
+		t.Errorf("WithStacks: got %d layers and %d stacks, want 3 and 1", layers, stacks)
+	}
+
+	for range WithStacks(err) {
+		break
+	}
+}
+
+func TestUnwrapGroupsPath(t *testing.T) {
+	err := &errWalkTest{
+		sub: []error{
+			&errWalkTest{v: 10},
+			&errWalkTest{v: 20, cause: &errWalkTest{v: 21}},
+		},
+	}
+
+	var got []string
+	for path, err := range UnwrapGroupsPath(err) {
+		got = append(got, fmt.Sprintf("%v=%v", path, err))
+	}
+	want := []string{"[]=0", "[0]=10", "[1]=20", "[1]=21"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnwrapGroupsPath: got: %v, want %v", got, want)
+	}
+}
+
+func TestIteratorsMatchWalkDeep(t *testing.T) {
+	// Each visited error is written as its path, its level and its message.
+	tests := []struct {
+		err  error
+		want []string
+	}{{
+		err:  nil,
+		want: nil,
+	}, {
+		err:  io.EOF,
+		want: []string{`[] 0 "EOF"`},
+	}, {
+		err: Annotate(WithMessage(New("origin"), "middle"), "outer"),
+		want: []string{
+			`[] 0 "outer: middle: origin"`,
+			`[] 1 "middle: origin"`,
+			`[] 2 "origin"`,
+		},
+	}, {
+		// a group wrapped by an annotation
+		err: Annotate(Join(io.EOF, &errWalkTest{v: 1, cause: &errWalkTest{v: 2}}), "read"),
+		want: []string{
+			`[] 0 "read: EOF\n1"`,
+			`[] 1 "read: EOF\n1"`,
+			`[] 2 "EOF\n1"`,
+			`[0] 3 "EOF"`,
+			`[1] 3 "1"`,
+			`[1] 4 "2"`,
+		},
+	}, {
+		// a standard library error wrapping several errors with %w
+		err: fmt.Errorf("read: %w, %w", io.EOF, &errWalkTest{v: 1, cause: &errWalkTest{v: 2}}),
+		want: []string{
+			`[] 0 "read: EOF, 1"`,
+			`[0] 1 "EOF"`,
+			`[1] 1 "1"`,
+			`[1] 2 "2"`,
+		},
+	}, {
+		err: &errWalkTest{
+			sub: []error{
+				&errWalkTest{v: 10, cause: &errWalkTest{v: 11}},
+				&errWalkTest{
+					v: 20,
+					sub: []error{
+						&errWalkTest{v: 21, cause: &errWalkTest{v: 22}},
+						&errWalkTest{v: 23},
+					},
+				},
+			},
+		},
+		want: []string{
+			`[] 0 "0"`,
+			`[0] 1 "10"`,
+			`[0] 2 "11"`,
+			`[1] 1 "20"`,
+			`[1 0] 2 "21"`,
+			`[1 0] 3 "22"`,
+			`[1 1] 2 "23"`,
+		},
+	}}
+
+	for i, tt := range tests {
+		var wantMessages []string
+		for _, entry := range tt.want {
+			wantMessages = append(wantMessages, entry[strings.Index(entry, `"`):])
+		}
+
+		var walked, withStacks, withPathsAndLevels []string
+		WalkDeep(tt.err, func(err error) bool {
+			walked = append(walked, strconv.Quote(err.Error()))
+			return false
+		})
+		for err := range WithStacks(tt.err) {
+			withStacks = append(withStacks, strconv.Quote(err.Error()))
+		}
+		var paths [][]int
+		for path := range UnwrapGroupsPath(tt.err) {
+			paths = append(paths, path)
+		}
+		var levels int
+		for level, err := range UnwrapGroupsLevel(tt.err) {
+			if levels < len(paths) {
+				withPathsAndLevels = append(withPathsAndLevels, fmt.Sprintf("%v %d %s", paths[levels], level, strconv.Quote(err.Error())))
+			}
+			levels++
+		}
+
+		if !reflect.DeepEqual(walked, wantMessages) {
+			t.Errorf("test %d: WalkDeep visited %v, want %v", i, walked, wantMessages)
+		}
+		if !reflect.DeepEqual(withStacks, wantMessages) {
+			t.Errorf("test %d: WithStacks visited %v, want %v", i, withStacks, wantMessages)
+		}
+		if len(paths) != levels || !reflect.DeepEqual(withPathsAndLevels, tt.want) {
+			t.Errorf("test %d: UnwrapGroupsPath visited %d errors and UnwrapGroupsLevel visited %v, want %v", i, len(paths), withPathsAndLevels, tt.want)
+		}
+	}
+}
+
+func TestUnwrapGroupsLevel(t *testing.T) {
+	err := &errWalkTest{
+		v: 1,
+		cause: &errWalkTest{
+			v: 2,
+			sub: []error{
+				&errWalkTest{v: 10},
+				&errWalkTest{v: 20, cause: &errWalkTest{v: 21}},
+			},
+		},
+	}
+
+	var got []string
+	for level, err := range UnwrapGroupsLevel(err) {
+		got = append(got, fmt.Sprintf("%d=%v", level, err))
+	}
+	want := []string{"0=1", "1=2", "2=10", "2=20", "3=21"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnwrapGroupsLevel: got: %v, want %v", got, want)
+	}
+
+	for range UnwrapGroupsLevel(err) {
+		break
+	}
+}